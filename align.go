@@ -0,0 +1,36 @@
+package mergeips
+
+import (
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// ErrIncorrectStep is returned by MergeAligned for the step not dividing the address length or greater than MaxAlignmentStep
+var ErrIncorrectStep = subnet.ErrIncorrectStep
+
+// MaxAlignmentStep is the greatest alignment step allowed, limiting the expansion of every prefix
+// to 2^(MaxAlignmentStep-1) aligned children, so ::/1 with IPv6 step of 64 is rejected instead of 2^63 /64s produced
+const MaxAlignmentStep = subnet.MaxAlignStep
+
+// Alignment defines the steps the prefix lengths of the merged list are to be multiples of, per family.
+// Zero value means no alignment.
+type Alignment struct {
+	IPv4 int
+	IPv6 int
+}
+
+// AlignmentDNS is the octet alignment for IPv4 and the nibble one for IPv6, as reverse DNS zones are
+var AlignmentDNS = Alignment{IPv4: 8, IPv6: 4} // nolint: gochecknoglobals
+
+// MergeAligned merges the list the same way Merge does, but with the prefix lengths multiples of the alignment steps only:
+// the unaligned prefixes are expanded into the aligned children, the siblings are merged only if the result stays aligned.
+// ErrIncorrectStep is returned for the step not dividing the address length or greater than MaxAlignmentStep.
+func MergeAligned(nets []*net.IPNet, alignment Alignment) ([]*net.IPNet, error) {
+	aligned, err := subnet.Align(subnet.Merge(subnet.FromIPNets(nets)), alignment.IPv4, alignment.IPv6)
+	if err != nil {
+		return nil, err
+	}
+
+	return subnet.IPNets(aligned), nil
+}
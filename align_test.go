@@ -0,0 +1,82 @@
+package mergeips_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testMergeAlignedRow struct {
+	in        []string
+	alignment mergeips.Alignment
+	expected  []string
+	err       error
+}
+
+var testMergeAlignedData = []testMergeAlignedRow{
+	{
+		in:        []string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24", "10.1.0.0/16", "192.168.0.0/30"},
+		alignment: mergeips.AlignmentDNS,
+		// 10.0.0.0/22 merged is not aligned, /30 is expanded to /32s
+		expected: []string{
+			"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24", "10.1.0.0/16",
+			"192.168.0.0/32", "192.168.0.1/32", "192.168.0.2/32", "192.168.0.3/32",
+		},
+	},
+	{
+		in:        []string{"10.0.0.0/9", "10.128.0.0/9", "2001:db8::/30", "2001:db8:1::/49"},
+		alignment: mergeips.AlignmentDNS,
+		expected:  []string{"10.0.0.0/8", "2001:db8::/32", "2001:db9::/32", "2001:dba::/32", "2001:dbb::/32"},
+	},
+	{
+		in:        []string{"10.0.0.0/15", "2001:db8::/31"},
+		alignment: mergeips.Alignment{IPv4: 16},
+		expected:  []string{"10.0.0.0/16", "10.1.0.0/16", "2001:db8::/31"},
+	},
+	{
+		in:        []string{"10.0.0.0/23", "2001:db8::/31"},
+		alignment: mergeips.Alignment{IPv6: 16},
+		expected:  []string{"10.0.0.0/23", "2001:db8::/32", "2001:db9::/32"},
+	},
+	{
+		in:        []string{"10.0.0.0/23"},
+		alignment: mergeips.Alignment{IPv4: 5},
+		err:       mergeips.ErrIncorrectStep,
+	},
+	{
+		in:        []string{"10.0.0.0/23"},
+		alignment: mergeips.Alignment{IPv6: -4},
+		err:       mergeips.ErrIncorrectStep,
+	},
+	{
+		// 2^63 /64s are never produced
+		in:        []string{"::/1"},
+		alignment: mergeips.Alignment{IPv6: 64},
+		err:       mergeips.ErrIncorrectStep,
+	},
+	{
+		in:        []string{"10.0.0.0/23"},
+		alignment: mergeips.Alignment{IPv4: 32},
+		err:       mergeips.ErrIncorrectStep,
+	},
+}
+
+func TestMergeAligned(t *testing.T) {
+	for _, row := range testMergeAlignedData {
+		nets, err := mergeips.Scan(&stringSliceScanner{data: row.in, next: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := mergeips.MergeAligned(nets, row.alignment)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%v: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		if diff := deep.Equal(netStrings(got), row.expected); err == nil && diff != nil {
+			t.Errorf("%v: %v", row.in, diff)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+// Command mergeips merges IP ranges and subnets read from the files or stdin
+//
+// Usage:
+//
+//	mergeips <command> [flags] [file...]
+//
+// Commands:
+//
+//	merge       print the minimal list of subnets covering the input, in the format chosen
+//	redundancy  print the input entries covered by other entries and the entries merged together
+//	diff        print the prefixes to remove from and to add to the merged old list to get the merged new one,
+//	            takes exactly two files, old and new
+//
+// Input is read from stdin if no files provided or the file name is "-"
+// merge accepts IPv6 zone identifiers, like fe80::/64%eth0, the prefixes of different zones are never merged
+// and written after the ones with no zone, with plain format only
+// gzip, bzip2 and zstd compressed input is decompressed, JSON and CSV content is detected, see mergeips.NewReader
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/formatter"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+// Errors
+var (
+	ErrUsage       = errors.New("usage: mergeips <command> [flags] [file...]")
+	ErrZonedFormat = errors.New("zone identifiers are supported by plain format only")
+)
+
+type command func(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error
+
+var commands = map[string]command{ // nolint: gochecknoglobals
+	"merge":      mergeCommand,
+	"redundancy": redundancyCommand,
+	"diff":       diffCommand,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || commands[args[0]] == nil {
+		fmt.Fprintf(stderr, "%v\ncommands: %s\n", ErrUsage, strings.Join(commandNames(), ", "))
+		return 2
+	}
+
+	if err := commands[args[0]](args[1:], stdin, stdout, stderr); err != nil {
+		// flag package reports the usage errors itself
+		if errors.Is(err, ErrUsage) {
+			return 2
+		}
+
+		fmt.Fprintln(stderr, err)
+
+		return 1
+	}
+
+	return 0
+}
+
+func mergeCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags, parser := parserFlags("merge", stderr)
+
+	var (
+		styleName = flags.String("format", formatter.StylePlain, "output format: "+strings.Join(formatter.StyleNames(), ", "))
+		tmplText  = flags.String("template", "", "Go text/template executed for every prefix, overrides -format")
+		options   formatter.Options
+		alignment mergeips.Alignment
+		deny      bool
+	)
+
+	flags.StringVar(&options.Name, "name", "", "list, set or table name")
+	flags.StringVar(&options.Name6, "name6", "", "IPv6 list, set or table name, if the format splits the families")
+	flags.StringVar(&options.Chain, "chain", "", "iptables chain")
+	flags.BoolVar(&options.Split, "split", false, "write IPv4 and IPv6 prefixes as separate groups")
+	flags.BoolVar(&deny, "deny", false, "make the prefixes denied instead of allowed")
+	flags.IntVar(&alignment.IPv4, "align4", 0, "IPv4 prefix lengths step, like 8 for octet boundaries, 0 for no alignment")
+	flags.IntVar(&alignment.IPv6, "align6", 0, "IPv6 prefix lengths step, like 4 for nibble boundaries, 0 for no alignment")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+
+	style, err := formatter.StyleByName(*styleName)
+	if err != nil {
+		return err
+	}
+
+	if deny {
+		options.Action = formatter.ActionDeny
+	}
+
+	in, err := readZoned(*parser, flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+
+	nets, zoned, err := mergeZoned(in, alignment)
+	if err != nil {
+		return err
+	}
+
+	if len(zoned) > 0 && (*tmplText != "" || *styleName != formatter.StylePlain) {
+		return ErrZonedFormat
+	}
+
+	if *tmplText != "" {
+		tmpl, err := template.New("prefix").Parse(*tmplText)
+		if err != nil {
+			return err
+		}
+
+		return formatter.FormatTemplate(stdout, nets, tmpl)
+	}
+
+	if err = formatter.Format(stdout, nets, style, options); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(stdout)
+
+	for _, z := range zoned {
+		fmt.Fprintln(w, z)
+	}
+
+	return w.Flush()
+}
+
+// mergeZoned merges and aligns every zone prefixes separately,
+// returning the prefixes with no zone apart from the zoned ones
+func mergeZoned(in []mergeips.ZonedNet, alignment mergeips.Alignment) ([]*net.IPNet, []mergeips.ZonedNet, error) {
+	var (
+		nets   []*net.IPNet
+		zoned  []mergeips.ZonedNet
+		byZone = make(map[string][]*net.IPNet)
+		zones  []string
+	)
+
+	// MergeZoned keeps the prefixes with no zone first, then the zones in lexical order
+	for _, z := range mergeips.MergeZoned(in) {
+		if _, ok := byZone[z.Zone]; !ok {
+			zones = append(zones, z.Zone)
+		}
+
+		byZone[z.Zone] = append(byZone[z.Zone], z.Net)
+	}
+
+	for _, zone := range zones {
+		list := byZone[zone]
+
+		if alignment != (mergeips.Alignment{}) {
+			var err error
+
+			if list, err = mergeips.MergeAligned(list, alignment); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if zone == "" {
+			nets = list
+			continue
+		}
+
+		for _, n := range list {
+			zoned = append(zoned, mergeips.ZonedNet{Net: n, Zone: zone})
+		}
+	}
+
+	return nets, zoned, nil
+}
+
+func redundancyCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags, parser := parserFlags("redundancy", stderr)
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+
+	entries, err := readEntries(*parser, flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+
+	report := mergeips.Redundancy(entries)
+	w := bufio.NewWriter(stdout)
+
+	for _, s := range report.Shadowed {
+		fmt.Fprintf(w, "%s is covered by %s\n", entryString(s.Entry), entryString(s.By))
+	}
+
+	for _, c := range report.Combined {
+		seen := make(map[mergeips.Source]bool, len(c.Entries))
+		parts := make([]string, 0, len(c.Entries))
+
+		for _, e := range c.Entries {
+			if !seen[e.Source] {
+				seen[e.Source] = true
+				parts = append(parts, e.Source.String()+": "+e.Source.Input)
+			}
+		}
+
+		fmt.Fprintf(w, "%s combines %s\n", c.Net, strings.Join(parts, ", "))
+	}
+
+	return w.Flush()
+}
+
+func diffCommand(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	flags, parser := parserFlags("diff", stderr)
+
+	var (
+		styleName = flags.String("format", formatter.StylePlain, "output format: "+strings.Join(formatter.DiffStyleNames(), ", "))
+		options   formatter.Options
+	)
+
+	flags.StringVar(&options.Name, "name", "", "set name")
+	flags.StringVar(&options.Name6, "name6", "", "IPv6 set name")
+	flags.StringVar(&options.Table, "table", "", "nftables table")
+
+	if err := flags.Parse(args); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "usage: mergeips diff [flags] old new")
+		return ErrUsage
+	}
+
+	style, err := formatter.DiffStyleByName(*styleName)
+	if err != nil {
+		return err
+	}
+
+	lists := make([][]*net.IPNet, 0, 2)
+
+	for _, name := range flags.Args() {
+		entries, err := readFile(*parser, name, stdin) // nolint: govet
+		if err != nil {
+			return err
+		}
+
+		nets := make([]*net.IPNet, 0, len(entries))
+
+		for _, e := range entries {
+			nets = append(nets, e.Net)
+		}
+
+		lists = append(lists, nets)
+	}
+
+	changes := mergeips.Diff(lists[0], lists[1])
+
+	return formatter.FormatDiff(stdout, changes.Add, changes.Remove, style, options)
+}
+
+func parserFlags(name string, output io.Writer) (*flag.FlagSet, *mergeips.Parser) {
+	var (
+		flags  = flag.NewFlagSet(name, flag.ContinueOnError)
+		parser = &mergeips.Parser{}
+	)
+
+	flags.SetOutput(output)
+	flags.BoolVar(&parser.Strict, "strict", false, "reject CIDRs with host bits set")
+	flags.BoolVar(&parser.SwapReversed, "swap", false, "accept ranges with begin greater than end")
+	flags.Var(mappedFlag{policy: &parser.Mapped}, "mapped", "IPv4-mapped IPv6 addresses policy: unmap, keep or reject")
+	flags.Var(resolveFlag{parser: parser}, "resolve", "expand hostnames to their addresses with the system resolver")
+
+	return flags, parser
+}
+
+var mappedPolicies = []string{ // nolint: gochecknoglobals
+	ipnet.MappedUnmap:  "unmap",
+	ipnet.MappedKeep:   "keep",
+	ipnet.MappedReject: "reject",
+}
+
+type mappedFlag struct {
+	policy *ipnet.MappedPolicy
+}
+
+func (f mappedFlag) String() string {
+	if f.policy == nil {
+		return mappedPolicies[ipnet.MappedUnmap]
+	}
+
+	return mappedPolicies[*f.policy]
+}
+
+func (f mappedFlag) Set(s string) error {
+	for policy, name := range mappedPolicies {
+		if name == s {
+			*f.policy = ipnet.MappedPolicy(policy)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q: unknown policy", s)
+}
+
+type resolveFlag struct {
+	parser *mergeips.Parser
+}
+
+func (f resolveFlag) IsBoolFlag() bool {
+	return true
+}
+
+func (f resolveFlag) String() string {
+	return strconv.FormatBool(f.parser != nil && f.parser.Resolver != nil)
+}
+
+func (f resolveFlag) Set(s string) error {
+	resolve, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+
+	f.parser.Resolver = nil
+	if resolve {
+		f.parser.Resolver = mergeips.NewCachingResolver(mergeips.NetResolver{})
+	}
+
+	return nil
+}
+
+func readEntries(parser mergeips.Parser, files []string, stdin io.Reader) ([]mergeips.Entry, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var res []mergeips.Entry
+
+	for _, name := range files {
+		entries, err := readFile(parser, name, stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, entries...)
+	}
+
+	return res, nil
+}
+
+func readZoned(parser mergeips.Parser, files []string, stdin io.Reader) ([]mergeips.ZonedNet, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var res []mergeips.ZonedNet
+
+	for _, name := range files {
+		err := scanFile(name, stdin, func(s mergeips.Scanner) error {
+			nets, err := parser.ScanZoned(s)
+			res = append(res, nets...)
+
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+func readFile(parser mergeips.Parser, name string, stdin io.Reader) (entries []mergeips.Entry, err error) {
+	err = scanFile(name, stdin, func(s mergeips.Scanner) error {
+		entries, err = parser.ScanSources(s, name)
+		return err
+	})
+
+	return entries, err
+}
+
+// scanFile calls scan with the Scanner over the file, stdin is read for "-"
+func scanFile(name string, stdin io.Reader, scan func(s mergeips.Scanner) error) error {
+	var r io.Reader = stdin
+
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		r = f
+	}
+
+	f, err := mergeips.NewFile(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer f.Close()
+
+	if err = scan(f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+func entryString(e mergeips.Entry) string {
+	if s := ipnet.String(e.Net); s != e.Source.Input {
+		return e.Source.String() + ": " + e.Source.Input + " (" + s + ")"
+	}
+
+	return e.Source.String() + ": " + e.Source.Input
+}
+
+func commandNames() []string {
+	res := make([]string, 0, len(commands))
+
+	for name := range commands {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
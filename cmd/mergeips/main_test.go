@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testRunRow struct {
+	args     []string
+	files    map[string]string
+	stdin    string
+	code     int
+	expected string
+}
+
+var testRunData = []testRunRow{
+	{
+		args:     []string{"merge"},
+		stdin:    "10.0.0.0/25\n10.0.0.128-10.0.0.255\n2001:db8::1\n",
+		expected: "10.0.0.0/24\n2001:db8::1/128\n",
+	},
+	{
+		args:  []string{"merge", "-strict"},
+		stdin: "10.0.0.5/24\n",
+		code:  1,
+	},
+	{
+		args:     []string{"merge", "-mapped", "keep", "-swap"},
+		stdin:    "::ffff:10.0.0.1\n10.0.0.3-10.0.0.2\n",
+		expected: "10.0.0.2/31\n::ffff:10.0.0.1/128\n",
+	},
+	{
+		args:     []string{"merge"},
+		stdin:    "fe80::%eth0\nfe80::1%eth1\n10.0.0.0/8\nfe80::2%eth1\nfe80::3%eth1\n",
+		expected: "10.0.0.0/8\nfe80::/128%eth0\nfe80::1/128%eth1\nfe80::2/127%eth1\n",
+	},
+	{
+		args:     []string{"merge", "-align6", "4"},
+		stdin:    "fe80::/63%eth0\n2001:db8::/31\n",
+		expected: "2001:db8::/32\n2001:db9::/32\nfe80::/64%eth0\nfe80:0:0:1::/64%eth0\n",
+	},
+	{
+		args:  []string{"merge", "-format", "nginx"},
+		stdin: "fe80::1%eth0\n",
+		code:  1,
+	},
+	{
+		args: []string{"redundancy", "a.txt", "b.txt"},
+		files: map[string]string{
+			"a.txt": "10.0.0.0/24\n10.0.1.0/25\n",
+			"b.txt": "10.0.0.7\n10.0.1.128-10.0.1.255\n",
+		},
+		expected: "b.txt:1: 10.0.0.7 (10.0.0.7/32) is covered by a.txt:1: 10.0.0.0/24\n" +
+			"10.0.0.0/23 combines a.txt:1: 10.0.0.0/24, a.txt:2: 10.0.1.0/25, b.txt:2: 10.0.1.128-10.0.1.255\n",
+	},
+	{
+		args: []string{"merge", "a.json", "b.csv"},
+		files: map[string]string{
+			"a.json": `["10.0.0.0/25", {"address": "2001:db8::1", "note": "lab"}]`,
+			"b.csv":  "name,cidr\noffice,10.0.0.128/25\n",
+		},
+		expected: "10.0.0.0/24\n2001:db8::1/128\n",
+	},
+	{
+		args:     []string{"merge", "-format", "nginx", "-deny"},
+		stdin:    "10.0.0.0/25\n10.0.0.128/25\n",
+		expected: "deny 10.0.0.0/24;\n",
+	},
+	{
+		args:     []string{"merge", "-align4", "8", "-align6", "4"},
+		stdin:    "10.0.0.0/23\n10.0.2.0/24\n2001:db8::/31\n",
+		expected: "10.0.0.0/24\n10.0.1.0/24\n10.0.2.0/24\n2001:db8::/32\n2001:db9::/32\n",
+	},
+	{
+		args:  []string{"merge", "-align4", "5"},
+		stdin: "10.0.0.0/23\n",
+		code:  1,
+	},
+	{
+		args:  []string{"merge", "-format", "cobol"},
+		stdin: "10.0.0.0/25\n",
+		code:  1,
+	},
+	{
+		args:     []string{"merge", "-template", "{{.First}} {{.Last}} {{.Count}}\n"},
+		stdin:    "10.0.0.0/25\n10.0.0.128/25\n",
+		expected: "10.0.0.0 10.0.0.255 256\n",
+	},
+	{
+		args: []string{"diff", "-format", "ipset", "-name", "allow", "old.txt", "new.txt"},
+		files: map[string]string{
+			"old.txt": "10.0.0.0/25\n10.0.0.128/25\n192.168.0.0/24\n",
+			"new.txt": "10.0.0.0/24\n192.168.1.0/24\n2001:db8::/32\n",
+		},
+		expected: "add allow 192.168.1.0/24\nadd allow6 2001:db8::/32\ndel allow 192.168.0.0/24\n",
+	},
+	{
+		args:  []string{"diff", "old.txt"},
+		files: map[string]string{"old.txt": "10.0.0.0/24\n"},
+		code:  2,
+	},
+	{
+		args: []string{"unknown"},
+		code: 2,
+	},
+	{
+		args: []string{"merge", "-mapped", "wrong"},
+		code: 2,
+	},
+}
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mergeips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, row := range testRunData {
+		args := make([]string, 0, len(row.args))
+
+		for _, arg := range row.args {
+			if content, ok := row.files[arg]; ok {
+				arg = filepath.Join(dir, arg)
+				if err := ioutil.WriteFile(arg, []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			args = append(args, arg)
+		}
+
+		var stdout, stderr bytes.Buffer
+
+		code := run(args, strings.NewReader(row.stdin), &stdout, &stderr)
+		if code != row.code {
+			t.Errorf("%v: got code %d, expected %d: %s", row.args, code, row.code, stderr.String())
+		}
+
+		if got := strings.ReplaceAll(stdout.String(), dir+string(filepath.Separator), ""); got != row.expected {
+			t.Errorf("%v: got %q, expected %q", row.args, got, row.expected)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mergeips
+
+import (
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Changes are the prefixes to be added and removed to turn one merged list into another
+type Changes struct {
+	Add    []*net.IPNet
+	Remove []*net.IPNet
+}
+
+// Diff merges both lists the same way Merge does and returns the changes
+// turning the merged old list into the merged new one, prefix by prefix:
+// Remove lists the prefixes of the old list missing in the new one,
+// Add lists the prefixes of the new list missing in the old one.
+// Both lists are ordered the same way Merge result is.
+func Diff(oldNets, newNets []*net.IPNet) Changes {
+	var (
+		a   = subnet.Merge(subnet.FromIPNets(oldNets))
+		b   = subnet.Merge(subnet.FromIPNets(newNets))
+		res Changes
+	)
+
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].Less(b[0]):
+			res.Remove = append(res.Remove, a[0].IPNet())
+			a = a[1:]
+		case len(a) == 0 || b[0].Less(a[0]):
+			res.Add = append(res.Add, b[0].IPNet())
+			b = b[1:]
+		default:
+			a, b = a[1:], b[1:]
+		}
+	}
+
+	return res
+}
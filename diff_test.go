@@ -0,0 +1,64 @@
+package mergeips_test
+
+import (
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testDiffRow struct {
+	old    []string
+	new    []string
+	add    []string
+	remove []string
+}
+
+var testDiffData = []testDiffRow{
+	{
+		old: []string{"10.0.0.0/25", "10.0.0.128/25", "192.168.0.0/24", "2001:db8::/32"},
+		new: []string{"10.0.0.0/24", "192.168.0.0/25", "2001:db8::/32", "2001:db8:1::/48"},
+		add: []string{"192.168.0.0/25"},
+		// 2001:db8::/32 already includes 2001:db8:1::/48, so it is the only one kept
+		remove: []string{"192.168.0.0/24"},
+	},
+	{
+		old:    []string{"10.0.0.0/24"},
+		new:    []string{"10.0.0.0/25", "10.0.1.0/24"},
+		add:    []string{"10.0.0.0/25", "10.0.1.0/24"},
+		remove: []string{"10.0.0.0/24"},
+	},
+	{
+		old:    []string{"10.0.0.0/24", "::1"},
+		new:    nil,
+		remove: []string{"10.0.0.0/24", "::1/128"},
+	},
+	{
+		old: []string{"10.0.0.0-10.0.0.255"},
+		new: []string{"10.0.0.0/24"},
+	},
+}
+
+func TestDiff(t *testing.T) {
+	for _, row := range testDiffData {
+		oldNets, err := mergeips.Scan(&stringSliceScanner{data: row.old, next: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newNets, err := mergeips.Scan(&stringSliceScanner{data: row.new, next: -1})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		changes := mergeips.Diff(oldNets, newNets)
+
+		if diff := deep.Equal(netStrings(changes.Add), row.add); diff != nil {
+			t.Errorf("%v -> %v: add: %v", row.old, row.new, diff)
+		}
+
+		if diff := deep.Equal(netStrings(changes.Remove), row.remove); diff != nil {
+			t.Errorf("%v -> %v: remove: %v", row.old, row.new, diff)
+		}
+	}
+}
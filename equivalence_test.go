@@ -0,0 +1,122 @@
+package mergeips_test
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+	"github.com/Djarvur/go-mergeips/ipnet"
+	"github.com/Djarvur/go-mergeips/iprange"
+	"github.com/go-test/deep"
+)
+
+const (
+	testEquivalenceRounds = 200
+	testEquivalenceSize   = 300
+)
+
+func TestEquivalence(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1)) // nolint: gosec
+
+	for round := 0; round < testEquivalenceRounds; round++ {
+		in := randomNets(rnd, testEquivalenceSize)
+		expected := mergeips.Merge(copyNets(in))
+
+		results := map[string][]*net.IPNet{
+			"ipnet.MergeSorted":          ipnet.MergeSorted(ipnet.DedupSorted(ipnet.Sort(copyNets(in)))),
+			"ipnet.MergeByRepeat":        ipnet.MergeByRepeat(copyNets(in)),
+			"subnet.Merge":               subnet.IPNets(subnet.Merge(subnet.FromIPNets(in))),
+			"subnet.MergeSortedByRepeat": subnet.IPNets(subnet.MergeSortedByRepeat(subnet.DedupSorted(subnet.Sort(subnet.FromIPNets(in))))),
+			"iprange.Merge":              mergeByRanges(copyNets(in)),
+		}
+
+		for name, merged := range results {
+			if diff := deep.Equal(merged, expected); diff != nil {
+				t.Errorf("round %d: %s: got %v, expected %v: %v", round, name, merged, expected, diff)
+			}
+		}
+	}
+}
+
+func TestEquivalenceFamilyOrder(t *testing.T) {
+	in := []*net.IPNet{
+		parseCIDR("::/0"),
+		{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(8, 32)},
+		parseCIDR("0.0.0.0/1"),
+	}
+	expected := []string{"0.0.0.0/1", "10.0.0.0/8", "::/0"}
+
+	sorted := ipnet.Sort(copyNets(in))
+	if diff := deep.Equal(netStrings(sorted), expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", sorted, expected, diff)
+	}
+
+	merged := mergeips.Merge(copyNets(in))
+	expected = []string{"0.0.0.0/1", "::/0"}
+
+	if diff := deep.Equal(netStrings(merged), expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", merged, expected, diff)
+	}
+}
+
+// mergeByRanges converts the list to the continuous ranges and merges every range with iprange.Merge
+func mergeByRanges(nets []*net.IPNet) (res []*net.IPNet) {
+	ips := subnet.DedupSorted(subnet.Sort(subnet.FromIPNets(nets)))
+
+	for i := 0; i < len(ips); {
+		begin, last := ips[i], ips[i]
+
+		for i++; i < len(ips) && ips[i].Bits == last.Bits && ips[i].IP.Cmp(last.IP.Jump(last.Mask().Mask)) == 0; i++ {
+			last = ips[i]
+		}
+
+		merged, err := iprange.Merge(begin.IP.IP(begin.Bits), last.IP.RangeEnd(last.Mask().Mask).IP(last.Bits))
+		if err != nil {
+			panic(err)
+		}
+
+		res = append(res, merged...)
+	}
+
+	return res
+}
+
+// randomNets generates the list of subnets clustered enough to be mergeable
+// IPv4 ones are randomly represented in 4 or 16 bytes form
+func randomNets(rnd *rand.Rand, n int) []*net.IPNet {
+	res := make([]*net.IPNet, 0, n)
+
+	for i := 0; i < n; i++ {
+		if rnd.Intn(4) == 0 {
+			ip := net.ParseIP("2001:db8::")
+			ip[14], ip[15] = byte(rnd.Intn(2)), byte(rnd.Intn(256))
+			ones := 128 - rnd.Intn(8)
+			res = append(res, &net.IPNet{IP: ip.Mask(net.CIDRMask(ones, 128)), Mask: net.CIDRMask(ones, 128)})
+
+			continue
+		}
+
+		ip := net.IPv4(10, 0, byte(rnd.Intn(4)), byte(rnd.Intn(256)))
+		ones := 32 - rnd.Intn(8)
+
+		if rnd.Intn(2) == 0 {
+			ip = ip.To4()
+		}
+
+		res = append(res, &net.IPNet{IP: ip.Mask(net.CIDRMask(ones, 32)), Mask: net.CIDRMask(ones, 32)})
+	}
+
+	return res
+}
+
+func copyNets(in []*net.IPNet) []*net.IPNet {
+	out := make([]*net.IPNet, 0, len(in))
+
+	for _, n := range in {
+		out = append(out, &net.IPNet{IP: n.IP, Mask: n.Mask})
+	}
+
+	return out
+}
@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+)
+
+// DiffStyle defines the output syntax of the changes: the prefixes removed are written with Remove style,
+// the prefixes added are written with Add one
+type DiffStyle struct {
+	Remove Style
+	Add    Style
+	// AddFirst makes the additions written before the removals, for the sets accepting overlapping elements,
+	// so the addresses kept are never missing from the set in between
+	AddFirst bool
+}
+
+var diffStyles = map[string]DiffStyle{ // nolint: gochecknoglobals
+	StylePlain: {
+		Remove: Style{Line: "-{prefix}\n"},
+		Add:    Style{Line: "+{prefix}\n"},
+	},
+	StyleIPSet: {
+		Remove: Style{Line: "del {name} {prefix}\n", Split: true},
+		Add:    Style{Line: "add {name} {prefix}\n", Split: true},
+		// hash:net sets accept overlapping elements and ipset restore is not atomic
+		AddFirst: true,
+	},
+	StyleNFTables: {
+		Remove: Style{Line: "delete element inet {table} {name} { {prefix} }\n", Split: true},
+		Add:    Style{Line: "add element inet {table} {name} { {prefix} }\n", Split: true},
+	},
+}
+
+// DiffStyleByName returns the named diff style, plain, ipset and nftables are available
+func DiffStyleByName(name string) (DiffStyle, error) {
+	style, ok := diffStyles[name]
+	if !ok {
+		return DiffStyle{}, fmt.Errorf("%q: %w", name, ErrUnknownStyle)
+	}
+
+	return style, nil
+}
+
+// DiffStyleNames returns the names of the diff styles available, sorted
+func DiffStyleNames() []string {
+	res := make([]string, 0, len(diffStyles))
+
+	for name := range diffStyles {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// FormatDiff writes the prefixes to be removed and the prefixes to be added in the style provided,
+// removals first unless the style is AddFirst one.
+// nftables interval sets reject the elements overlapping existing ones, so the removals go first for nftables style.
+func FormatDiff(w io.Writer, add, remove []*net.IPNet, style DiffStyle, options Options) error {
+	first, second := style.Remove, style.Add
+	firstList, secondList := remove, add
+
+	if style.AddFirst {
+		first, second = style.Add, style.Remove
+		firstList, secondList = add, remove
+	}
+
+	if err := Format(w, firstList, first, options); err != nil {
+		return err
+	}
+
+	return Format(w, secondList, second, options)
+}
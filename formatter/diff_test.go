@@ -0,0 +1,69 @@
+package formatter_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips/formatter"
+)
+
+type testFormatDiffRow struct {
+	style    string
+	add      []*net.IPNet
+	remove   []*net.IPNet
+	options  formatter.Options
+	expected string
+}
+
+var testFormatDiffData = []testFormatDiffRow{
+	{
+		style:    formatter.StylePlain,
+		add:      testNets[:1],
+		remove:   testNets[1:],
+		expected: "-192.168.0.0/24\n-2001:db8::/32\n+10.0.0.0/8\n",
+	},
+	{
+		style:    formatter.StyleIPSet,
+		add:      testNets[1:],
+		remove:   testNets[:1],
+		options:  formatter.Options{Name: "allow"},
+		expected: "add allow 192.168.0.0/24\nadd allow6 2001:db8::/32\ndel allow 10.0.0.0/8\n",
+	},
+	{
+		style:   formatter.StyleNFTables,
+		add:     testNets[2:],
+		remove:  testNets[:1],
+		options: formatter.Options{Name: "allow4", Name6: "allow6", Table: "fw"},
+		expected: "delete element inet fw allow4 { 10.0.0.0/8 }\n" +
+			"add element inet fw allow6 { 2001:db8::/32 }\n",
+	},
+	{
+		style:    formatter.StyleNFTables,
+		expected: "",
+	},
+}
+
+func TestFormatDiff(t *testing.T) {
+	for _, row := range testFormatDiffData {
+		style, err := formatter.DiffStyleByName(row.style)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+
+		if err := formatter.FormatDiff(&buf, row.add, row.remove, style, row.options); err != nil {
+			t.Errorf("%s: %v", row.style, err)
+		}
+
+		if got := buf.String(); got != row.expected {
+			t.Errorf("%s: got %q, expected %q", row.style, got, row.expected)
+		}
+	}
+
+	if _, err := formatter.DiffStyleByName(formatter.StyleCisco); !errors.Is(err, formatter.ErrUnknownStyle) {
+		t.Errorf("got %v, expected %v", err, formatter.ErrUnknownStyle)
+	}
+}
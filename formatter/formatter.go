@@ -0,0 +1,267 @@
+// Package formatter writes the list of net.IPNet, like the one returned by mergeips.Merge,
+// in the syntax of firewall and router configurations
+package formatter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+// Errors
+var (
+	ErrUnknownStyle = errors.New("unknown style")
+)
+
+// Action is the action the prefixes listed are for
+type Action int
+
+// Actions available
+const (
+	ActionAllow Action = iota
+	ActionDeny
+)
+
+// Style defines the output syntax with per-line templates
+// Templates might contain the placeholders:
+// {name} - list name, Options.Name for IPv4 group, Options.Name6 for IPv6 one
+// {family} - Family4 or Family6 value, depending on the group
+// {action} - Allow or Deny value, depending on Options.Action
+// {chain} - Options.Chain
+// {table} - Options.Table
+// {prefix} - prefix in CIDR notation, Line only
+// {address} - prefix address, Line only
+// {length} - prefix length, Line only
+// {index} - 0-based prefix index in the group, Line only
+// {seq} - 5-stepped sequence number, like 5, 10, 15, Line only
+type Style struct {
+	// Header is written before every group
+	Header string
+	// Line is written for every prefix
+	Line string
+	// Separator is written between the lines of the group
+	Separator string
+	// Footer is written after every group
+	Footer string
+	// Split makes IPv4 and IPv6 prefixes written as separate groups, IPv4 first
+	// Otherwise all the prefixes are written as a single group
+	Split bool
+	// Family4 is {family} value for IPv4 group, like "iptables" or "inet"
+	Family4 string
+	// Family6 is {family} value for IPv6 group, like "ip6tables" or "inet6"
+	Family6 string
+	// Allow is {action} value for ActionAllow, like "ACCEPT" or "permit"
+	Allow string
+	// Deny is {action} value for ActionDeny, like "DROP" or "deny"
+	Deny string
+}
+
+// Options are the values for the style placeholders
+type Options struct {
+	// Name is the list, set or table name, "mergeips" if empty
+	Name string
+	// Name6 is the name of IPv6 group for the styles splitting the families, Name + "6" if empty
+	Name6 string
+	// Chain is the chain name for iptables style, "INPUT" if empty
+	Chain string
+	// Table is the table name for nftables diff style, "filter" if empty
+	Table string
+	// Action is the action the prefixes listed are for
+	Action Action
+	// Split forces IPv4 and IPv6 prefixes to be written as separate groups for any style
+	Split bool
+}
+
+// Named styles
+const (
+	StylePlain    = "plain"
+	StyleIPTables = "iptables"
+	StyleNFTables = "nftables"
+	StyleIPSet    = "ipset"
+	StyleCisco    = "cisco"
+	StyleJuniper  = "juniper"
+	StyleBIRD     = "bird"
+	StylePF       = "pf"
+	StyleNginx    = "nginx"
+)
+
+var styles = map[string]Style{ // nolint: gochecknoglobals
+	StylePlain: {
+		Line: "{prefix}\n",
+	},
+	StyleIPTables: {
+		Line:    "{family} -A {chain} -s {prefix} -j {action}\n",
+		Split:   true,
+		Family4: "iptables",
+		Family6: "ip6tables",
+		Allow:   "ACCEPT",
+		Deny:    "DROP",
+	},
+	StyleNFTables: {
+		Header:    "set {name} {\n\ttype {family}\n\tflags interval\n\telements = {\n",
+		Line:      "\t\t{prefix}",
+		Separator: ",\n",
+		Footer:    "\n\t}\n}\n",
+		Split:     true,
+		Family4:   "ipv4_addr",
+		Family6:   "ipv6_addr",
+	},
+	StyleIPSet: {
+		Header:  "create {name} hash:net family {family}\n",
+		Line:    "add {name} {prefix}\n",
+		Split:   true,
+		Family4: "inet",
+		Family6: "inet6",
+	},
+	StyleCisco: {
+		Line:    "{family} prefix-list {name} seq {seq} {action} {prefix}\n",
+		Split:   true,
+		Family4: "ip",
+		Family6: "ipv6",
+		Allow:   "permit",
+		Deny:    "deny",
+	},
+	StyleJuniper: {
+		Line: "set policy-options prefix-list {name} {prefix}\n",
+	},
+	StyleBIRD: {
+		Header:    "define {name} = [\n",
+		Line:      "\t{prefix}",
+		Separator: ",\n",
+		Footer:    "\n];\n",
+		Split:     true,
+	},
+	StylePF: {
+		Header:    "table <{name}> persist {\n",
+		Line:      "\t{prefix}",
+		Separator: ",\n",
+		Footer:    "\n}\n",
+	},
+	StyleNginx: {
+		Line:  "{action} {prefix};\n",
+		Allow: "allow",
+		Deny:  "deny",
+	},
+}
+
+// StyleByName returns the named style
+func StyleByName(name string) (Style, error) {
+	style, ok := styles[name]
+	if !ok {
+		return Style{}, fmt.Errorf("%q: %w", name, ErrUnknownStyle)
+	}
+
+	return style, nil
+}
+
+// StyleNames returns the names of the styles available, sorted
+func StyleNames() []string {
+	res := make([]string, 0, len(styles))
+
+	for name := range styles {
+		res = append(res, name)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// Format writes the prefixes in the style provided
+// Prefixes are written in the order provided, the groups with no prefixes are not written at all
+func Format(w io.Writer, nets []*net.IPNet, style Style, options Options) error {
+	options = options.withDefaults()
+	bw := bufio.NewWriter(w)
+
+	if !style.Split && !options.Split {
+		writeGroup(bw, nets, style, style.Family4, options.Name, options)
+		return bw.Flush()
+	}
+
+	var v4, v6 []*net.IPNet
+
+	for _, n := range nets {
+		if subnet.FromIPNet(n).Bits == 32 {
+			v4 = append(v4, n)
+		} else {
+			v6 = append(v6, n)
+		}
+	}
+
+	writeGroup(bw, v4, style, style.Family4, options.Name, options)
+	writeGroup(bw, v6, style, style.Family6, options.Name6, options)
+
+	return bw.Flush()
+}
+
+func writeGroup(w *bufio.Writer, nets []*net.IPNet, style Style, family string, name string, options Options) {
+	if len(nets) == 0 {
+		return
+	}
+
+	action := style.Allow
+	if options.Action == ActionDeny {
+		action = style.Deny
+	}
+
+	group := strings.NewReplacer(
+		"{name}", name,
+		"{family}", family,
+		"{action}", action,
+		"{chain}", options.Chain,
+		"{table}", options.Table,
+	)
+
+	w.WriteString(group.Replace(style.Header)) // nolint: errcheck
+
+	for i, n := range nets {
+		if i > 0 {
+			w.WriteString(style.Separator) // nolint: errcheck
+		}
+
+		var (
+			ones, _ = n.Mask.Size()
+			prefix  = ipnet.String(n)
+		)
+
+		line := strings.NewReplacer(
+			"{prefix}", prefix,
+			"{address}", prefix[:strings.IndexByte(prefix, '/')],
+			"{length}", strconv.Itoa(ones),
+			"{index}", strconv.Itoa(i),
+			"{seq}", strconv.Itoa((i+1)*5),
+		)
+
+		w.WriteString(group.Replace(line.Replace(style.Line))) // nolint: errcheck
+	}
+
+	w.WriteString(group.Replace(style.Footer)) // nolint: errcheck
+}
+
+func (o Options) withDefaults() Options {
+	if o.Name == "" {
+		o.Name = "mergeips"
+	}
+
+	if o.Name6 == "" {
+		o.Name6 = o.Name + "6"
+	}
+
+	if o.Chain == "" {
+		o.Chain = "INPUT"
+	}
+
+	if o.Table == "" {
+		o.Table = "filter"
+	}
+
+	return o
+}
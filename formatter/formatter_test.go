@@ -0,0 +1,158 @@
+package formatter_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips/formatter"
+)
+
+var testNets = []*net.IPNet{
+	parseCIDR("10.0.0.0/8"),
+	parseCIDR("192.168.0.0/24"),
+	parseCIDR("2001:db8::/32"),
+}
+
+type testFormatRow struct {
+	style    string
+	nets     []*net.IPNet
+	options  formatter.Options
+	expected string
+}
+
+var testFormatData = []testFormatRow{
+	{
+		style:    formatter.StylePlain,
+		nets:     testNets,
+		expected: "10.0.0.0/8\n192.168.0.0/24\n2001:db8::/32\n",
+	},
+	{
+		style:    formatter.StylePlain,
+		nets:     nil,
+		expected: "",
+	},
+	{
+		style:   formatter.StyleIPTables,
+		nets:    testNets,
+		options: formatter.Options{Chain: "FORWARD", Action: formatter.ActionDeny},
+		expected: "iptables -A FORWARD -s 10.0.0.0/8 -j DROP\n" +
+			"iptables -A FORWARD -s 192.168.0.0/24 -j DROP\n" +
+			"ip6tables -A FORWARD -s 2001:db8::/32 -j DROP\n",
+	},
+	{
+		style:   formatter.StyleNFTables,
+		nets:    testNets,
+		options: formatter.Options{Name: "allow4", Name6: "allow6"},
+		expected: "set allow4 {\n\ttype ipv4_addr\n\tflags interval\n\telements = {\n\t\t10.0.0.0/8,\n\t\t192.168.0.0/24\n\t}\n}\n" +
+			"set allow6 {\n\ttype ipv6_addr\n\tflags interval\n\telements = {\n\t\t2001:db8::/32\n\t}\n}\n",
+	},
+	{
+		style:   formatter.StyleIPSet,
+		nets:    testNets,
+		options: formatter.Options{Name: "block"},
+		expected: "create block hash:net family inet\nadd block 10.0.0.0/8\nadd block 192.168.0.0/24\n" +
+			"create block6 hash:net family inet6\nadd block6 2001:db8::/32\n",
+	},
+	{
+		style:   formatter.StyleCisco,
+		nets:    testNets,
+		options: formatter.Options{Name: "CUSTOMERS", Name6: "CUSTOMERS-V6"},
+		expected: "ip prefix-list CUSTOMERS seq 5 permit 10.0.0.0/8\nip prefix-list CUSTOMERS seq 10 permit 192.168.0.0/24\n" +
+			"ipv6 prefix-list CUSTOMERS-V6 seq 5 permit 2001:db8::/32\n",
+	},
+	{
+		style:   formatter.StyleJuniper,
+		nets:    testNets,
+		options: formatter.Options{Name: "customers"},
+		expected: "set policy-options prefix-list customers 10.0.0.0/8\n" +
+			"set policy-options prefix-list customers 192.168.0.0/24\n" +
+			"set policy-options prefix-list customers 2001:db8::/32\n",
+	},
+	{
+		style:    formatter.StyleBIRD,
+		nets:     testNets,
+		options:  formatter.Options{Name: "customers", Name6: "customers_v6"},
+		expected: "define customers = [\n\t10.0.0.0/8,\n\t192.168.0.0/24\n];\ndefine customers_v6 = [\n\t2001:db8::/32\n];\n",
+	},
+	{
+		style:    formatter.StylePF,
+		nets:     testNets,
+		options:  formatter.Options{Name: "goodguys"},
+		expected: "table <goodguys> persist {\n\t10.0.0.0/8,\n\t192.168.0.0/24,\n\t2001:db8::/32\n}\n",
+	},
+	{
+		style:    formatter.StyleNginx,
+		nets:     testNets,
+		options:  formatter.Options{Action: formatter.ActionDeny},
+		expected: "deny 10.0.0.0/8;\ndeny 192.168.0.0/24;\ndeny 2001:db8::/32;\n",
+	},
+	{
+		style:    formatter.StyleNginx,
+		nets:     testNets[2:],
+		expected: "allow 2001:db8::/32;\n",
+	},
+	{
+		style:    formatter.StyleJuniper,
+		nets:     testNets[1:],
+		options:  formatter.Options{Name: "v4", Name6: "v6", Split: true},
+		expected: "set policy-options prefix-list v4 192.168.0.0/24\nset policy-options prefix-list v6 2001:db8::/32\n",
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, row := range testFormatData {
+		style, err := formatter.StyleByName(row.style)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+
+		if err := formatter.Format(&buf, row.nets, style, row.options); err != nil {
+			t.Errorf("%s: %v", row.style, err)
+		}
+
+		if got := buf.String(); got != row.expected {
+			t.Errorf("%s: got %q, expected %q", row.style, got, row.expected)
+		}
+	}
+}
+
+func TestFormatCustomStyle(t *testing.T) {
+	var (
+		buf   bytes.Buffer
+		style = formatter.Style{Line: "route {address} len {length} #{index}\n"}
+	)
+
+	if err := formatter.Format(&buf, testNets[:2], style, formatter.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "route 10.0.0.0 len 8 #0\nroute 192.168.0.0 len 24 #1\n"
+	if got := buf.String(); got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestStyleByName(t *testing.T) {
+	if _, err := formatter.StyleByName("cobol"); !errors.Is(err, formatter.ErrUnknownStyle) {
+		t.Errorf("got %v, expected %v", err, formatter.ErrUnknownStyle)
+	}
+
+	for _, name := range formatter.StyleNames() {
+		if _, err := formatter.StyleByName(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func parseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
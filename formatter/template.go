@@ -0,0 +1,78 @@
+package formatter
+
+import (
+	"bufio"
+	"io"
+	"math/big"
+	"net"
+	"text/template"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Prefix is the data the template is executed with for every prefix
+type Prefix struct {
+	// Prefix is the prefix in CIDR notation
+	Prefix string
+	// Network is the prefix address
+	Network net.IP
+	// Length is the prefix length
+	Length int
+	// Bits is the address length, 32 or 128
+	Bits int
+	// First is the first address of the prefix, the same as Network
+	First net.IP
+	// Last is the last address of the prefix
+	Last net.IP
+	// Netmask is the prefix mask in the address notation
+	Netmask net.IP
+	// Wildcard is the inverted Netmask, like Cisco ACLs expect
+	Wildcard net.IP
+	// Count is the number of addresses in the prefix
+	Count *big.Int
+	// Family is "IPv4" or "IPv6"
+	Family string
+	// Index is 0-based prefix index in the list
+	Index int
+}
+
+// PrefixOf returns the template data for the prefix with the index provided
+func PrefixOf(n *net.IPNet, index int) Prefix {
+	var (
+		s    = subnet.FromIPNet(n)
+		mask = s.Mask()
+	)
+
+	family := "IPv6"
+	if s.Bits == 32 {
+		family = "IPv4"
+	}
+
+	return Prefix{
+		Prefix:   s.String(),
+		Network:  s.IP.IP(s.Bits),
+		Length:   s.Ones,
+		Bits:     s.Bits,
+		First:    s.IP.IP(s.Bits),
+		Last:     s.IP.RangeEnd(mask.Mask).IP(s.Bits),
+		Netmask:  mask.Mask.IP(s.Bits),
+		Wildcard: mask.Mask.Not().IP(s.Bits),
+		Count:    mask.Size.BigInt(),
+		Family:   family,
+		Index:    index,
+	}
+}
+
+// FormatTemplate executes the template for every prefix, in the order provided
+// See Prefix for the data available
+func FormatTemplate(w io.Writer, nets []*net.IPNet, tmpl *template.Template) error {
+	bw := bufio.NewWriter(w)
+
+	for i, n := range nets {
+		if err := tmpl.Execute(bw, PrefixOf(n, i)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
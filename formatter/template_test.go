@@ -0,0 +1,53 @@
+package formatter_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"text/template"
+
+	"github.com/Djarvur/go-mergeips/formatter"
+)
+
+type testTemplateRow struct {
+	template string
+	nets     []*net.IPNet
+	expected string
+}
+
+var testTemplateData = []testTemplateRow{
+	{
+		template: "{{.Index}} {{.Family}} {{.Network}}/{{.Length}} {{.First}}-{{.Last}} {{.Netmask}} {{.Wildcard}} {{.Count}}\n",
+		nets:     []*net.IPNet{parseCIDR("10.0.0.0/8"), parseCIDR("192.168.0.4/30"), parseCIDR("0.0.0.0/0")},
+		expected: "0 IPv4 10.0.0.0/8 10.0.0.0-10.255.255.255 255.0.0.0 0.255.255.255 16777216\n" +
+			"1 IPv4 192.168.0.4/30 192.168.0.4-192.168.0.7 255.255.255.252 0.0.0.3 4\n" +
+			"2 IPv4 0.0.0.0/0 0.0.0.0-255.255.255.255 0.0.0.0 255.255.255.255 4294967296\n",
+	},
+	{
+		template: "{{.Prefix}} {{.Last}} {{.Count}} {{.Bits}}\n",
+		nets:     []*net.IPNet{parseCIDR("2001:db8::/32"), parseCIDR("::/0")},
+		expected: "2001:db8::/32 2001:db8:ffff:ffff:ffff:ffff:ffff:ffff 79228162514264337593543950336 128\n" +
+			"::/0 ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff 340282366920938463463374607431768211456 128\n",
+	},
+	{
+		template: `access-list 10 permit {{.Network}} {{.Wildcard}}{{"\n"}}`,
+		nets:     []*net.IPNet{{IP: net.ParseIP("172.16.0.0"), Mask: net.CIDRMask(12, 32)}},
+		expected: "access-list 10 permit 172.16.0.0 0.15.255.255\n",
+	},
+}
+
+func TestFormatTemplate(t *testing.T) {
+	for _, row := range testTemplateData {
+		tmpl := template.Must(template.New("test").Parse(row.template))
+
+		var buf bytes.Buffer
+
+		if err := formatter.FormatTemplate(&buf, row.nets, tmpl); err != nil {
+			t.Errorf("%s: %v", row.template, err)
+		}
+
+		if got := buf.String(); got != row.expected {
+			t.Errorf("got %q, expected %q", got, row.expected)
+		}
+	}
+}
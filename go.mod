@@ -4,5 +4,7 @@ go 1.13
 
 require (
 	github.com/go-test/deep v1.0.4
+	github.com/klauspost/compress v1.13.1
+	gopkg.in/yaml.v2 v2.4.0
 	lukechampine.com/uint128 v1.0.0
 )
@@ -0,0 +1,131 @@
+package mergeips
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Resolver looks up the addresses of a hostname, see NetResolver and CachingResolver
+// IPv4 addresses might be 4 or 16 bytes net.IP, both are taken for IPv4 ones
+type Resolver interface {
+	LookupIP(ctx context.Context, host string) ([]net.IP, error)
+}
+
+// NetResolver is Resolver using *net.Resolver, net.DefaultResolver if nil
+type NetResolver struct {
+	Resolver *net.Resolver
+}
+
+// LookupIP implements Resolver
+func (r NetResolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	addrs, err := r.Resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]net.IP, 0, len(addrs))
+
+	// net.Resolver returns IPv4 addresses as 16 bytes ones for some sources, like hosts file
+	for _, a := range addrs {
+		ip := a.IP
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+
+		res = append(res, ip)
+	}
+
+	return res, nil
+}
+
+// CachingResolver is Resolver looking every hostname up once,
+// the failures and the empty results are not cached, so they are looked up every time
+// Safe for concurrent use
+type CachingResolver struct {
+	resolver Resolver
+	mu       sync.Mutex
+	cache    map[string][]net.IP
+}
+
+// NewCachingResolver returns CachingResolver caching the addresses r returns
+func NewCachingResolver(r Resolver) *CachingResolver {
+	return &CachingResolver{resolver: r, cache: make(map[string][]net.IP)}
+}
+
+// LookupIP implements Resolver
+func (r *CachingResolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+	r.mu.Lock()
+	ips, ok := r.cache[host]
+	r.mu.Unlock()
+
+	if ok {
+		return ips, nil
+	}
+
+	ips, err := r.resolver.LookupIP(ctx, host)
+	if err != nil || len(ips) == 0 {
+		return ips, err
+	}
+
+	r.mu.Lock()
+	r.cache[host] = ips
+	r.mu.Unlock()
+
+	return ips, nil
+}
+
+// parseHostname returns /32 and /128 subnets of the hostname addresses.
+// The addresses resolved have no notation, so IPv4 ones are never taken for IPv4-mapped IPv6 ones
+// and the policy is not applied to them, the same way it is not to the literal addresses in IPv4 notation.
+func (p Parser) parseHostname(ctx context.Context, s string) ([]*net.IPNet, error) {
+	ips, err := p.Resolver.LookupIP(ctx, s)
+	if err == nil && len(ips) == 0 {
+		err = ErrNoAddresses
+	}
+
+	if err != nil {
+		return nil, &ParseError{Input: s, Reason: ReasonLookup, Err: err}
+	}
+
+	res := make([]*net.IPNet, 0, len(ips))
+
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+
+		bits := len(ip) * 8
+		res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return res, nil
+}
+
+// isHostname returns true for the valid hostname with the top level label not all-numeric,
+// so IPv4 addresses and ranges are never taken for hostnames
+func isHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	labels := strings.Split(s, ".")
+
+	for _, label := range labels {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+
+	return strings.Trim(labels[len(labels)-1], "0123456789") != ""
+}
@@ -0,0 +1,154 @@
+package mergeips_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/ipnet"
+	"github.com/go-test/deep"
+)
+
+// fakeResolver resolves the hostnames from the map, counting the lookups
+type fakeResolver struct {
+	hosts   map[string][]string
+	lookups int
+}
+
+var errNotFound = errors.New("not found")
+
+func (r *fakeResolver) LookupIP(_ context.Context, host string) ([]net.IP, error) {
+	r.lookups++
+
+	addrs, ok := r.hosts[host]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	res := make([]net.IP, 0, len(addrs))
+
+	// IPv4 addresses are 16 bytes, the way net.Resolver returns them for hosts file
+	for _, a := range addrs {
+		res = append(res, net.ParseIP(a))
+	}
+
+	return res, nil
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{hosts: map[string][]string{
+		"api.partner.example": {"192.0.2.10", "192.0.2.11", "2001:db8::10"},
+		"web-1.example":       {"198.51.100.7"},
+		"empty.example":       nil,
+		"mapped.example":      {"::ffff:192.0.2.12"},
+	}}
+}
+
+type testParseHostnameRow struct {
+	in       string
+	mapped   ipnet.MappedPolicy
+	expected []string
+	err      error
+}
+
+var testParseHostnameData = []testParseHostnameRow{
+	{in: "api.partner.example", expected: []string{"192.0.2.10/32", "192.0.2.11/32", "2001:db8::10/128"}},
+	{in: "api.partner.example.", expected: []string{"192.0.2.10/32", "192.0.2.11/32", "2001:db8::10/128"}},
+	{in: "web-1.example", expected: []string{"198.51.100.7/32"}},
+	{in: "10.0.0.1-10.0.0.2", expected: []string{"10.0.0.1/32", "10.0.0.2/32"}},
+	{in: "10.0.0.0/31", expected: []string{"10.0.0.0/31"}},
+	{in: "missing.example", err: errNotFound},
+	{in: "empty.example", err: mergeips.ErrNoAddresses},
+	{in: "-bad.example", err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.256", err: mergeips.ErrInputInvalid},
+	{in: "mapped.example", expected: []string{"192.0.2.12/32"}},
+	{in: "mapped.example", mapped: mergeips.MappedKeep, expected: []string{"192.0.2.12/32"}},
+	{in: "mapped.example", mapped: mergeips.MappedReject, expected: []string{"192.0.2.12/32"}},
+	{in: "web-1.example", mapped: mergeips.MappedKeep, expected: []string{"198.51.100.7/32"}},
+	{in: "web-1.example", mapped: mergeips.MappedReject, expected: []string{"198.51.100.7/32"}},
+}
+
+func TestParseHostname(t *testing.T) {
+	resolver := newFakeResolver()
+	caching := mergeips.NewCachingResolver(resolver)
+
+	for _, row := range testParseHostnameData {
+		parser := mergeips.Parser{Resolver: caching, Mapped: row.mapped}
+
+		nets, err := parser.ParseContext(context.Background(), row.in)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		var perr *mergeips.ParseError
+		if row.err != nil && row.err != mergeips.ErrInputInvalid && (!errors.As(err, &perr) || perr.Reason != mergeips.ReasonLookup) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, mergeips.ReasonLookup)
+		}
+
+		if diff := deep.Equal(netStrings(nets), row.expected); diff != nil {
+			t.Errorf("%s: %v", row.in, diff)
+		}
+	}
+
+	// every hostname found is looked up once
+	if resolver.lookups != 5 {
+		t.Errorf("got %d lookups, expected 5", resolver.lookups)
+	}
+
+	// the failures and the empty results are looked up every time
+	for _, host := range []string{"missing.example", "empty.example", "missing.example"} {
+		if _, err := (mergeips.Parser{Resolver: caching}).Parse(host); err == nil {
+			t.Errorf("%s: error expected", host)
+		}
+	}
+
+	if resolver.lookups != 8 {
+		t.Errorf("got %d lookups, expected 8", resolver.lookups)
+	}
+
+	if _, err := (mergeips.Parser{}).Parse("api.partner.example"); !errors.Is(err, mergeips.ErrInputInvalid) {
+		t.Errorf("got error %v, expected %v", err, mergeips.ErrInputInvalid)
+	}
+}
+
+func TestScanHostname(t *testing.T) {
+	parser := mergeips.Parser{Resolver: newFakeResolver()}
+
+	nets, err := parser.Scan(&stringSliceScanner{data: []string{"192.0.2.8/30", "api.partner.example", "web-1.example"}, next: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(netStrings(mergeips.Merge(nets)), []string{"192.0.2.8/30", "198.51.100.7/32", "2001:db8::10/128"}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+func TestNetResolver(t *testing.T) {
+	ips, err := mergeips.NetResolver{}.LookupIP(context.Background(), "localhost")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	for _, ip := range ips {
+		if ip.To4() != nil && len(ip) != net.IPv4len {
+			t.Errorf("got %d bytes IPv4 address %s", len(ip), ip)
+		}
+	}
+
+	for _, policy := range []ipnet.MappedPolicy{mergeips.MappedUnmap, mergeips.MappedKeep, mergeips.MappedReject} {
+		nets, err := mergeips.Parser{Resolver: mergeips.NetResolver{}, Mapped: policy}.Parse("localhost")
+		if err != nil {
+			t.Errorf("%d: %v", policy, err)
+		}
+
+		for _, n := range nets {
+			if s := ipnet.String(n); strings.HasPrefix(s, "::ffff:") {
+				t.Errorf("%d: got %s", policy, s)
+			}
+		}
+	}
+}
@@ -29,3 +29,8 @@ func (x Big) IsZero() bool {
 
 	return cmp == 0
 }
+
+// BigInt returns the value as a new big.Int
+func (x Big) BigInt() *big.Int {
+	return big.NewInt(0).Set(x.Int)
+}
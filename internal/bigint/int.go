@@ -10,6 +10,7 @@ type Int interface {
 	SetBit(i int) Int
 	Sub(n Int) Int
 	IsZero() bool
+	BigInt() *big.Int
 }
 
 // IntByBits exported func should have comment or be unexported
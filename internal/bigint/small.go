@@ -1,6 +1,8 @@
 // Package bigint comment should be of this form
 package bigint
 
+import "math/big"
+
 // Small exported type should have comment or be unexported
 type Small int64
 
@@ -22,3 +24,8 @@ func (x Small) IsZero() bool {
 
 	return x == 0
 }
+
+// BigInt returns the value as a new big.Int
+func (x Small) BigInt() *big.Int {
+	return big.NewInt(int64(x))
+}
@@ -21,14 +21,29 @@ func Uint128FromUint64s(high, low uint64) Uint128 {
 	return Uint128{high: high, low: low}
 }
 
+// Uint128FromBigInt converts non-negative x less than 2^128 to Uint128, ErrInvalidData returned otherwise
+func Uint128FromBigInt(x *big.Int) (Uint128, error) {
+	if x.Sign() < 0 || x.BitLen() > 128 {
+		return Uint128{}, ErrInvalidData
+	}
+
+	b := make([]byte, 16)
+	xb := x.Bytes()
+	copy(b[16-len(xb):], xb)
+
+	return Uint128{high: binary.BigEndian.Uint64(b[:8]), low: binary.BigEndian.Uint64(b[8:])}, nil
+}
+
 // ErrInvalidData exported var should have comment or be unexported
 var ErrInvalidData = errors.New("invalid data")
 
-// Uint128FromIP exported func should have comment or be unexported
+// Uint128FromIP converts IP to Uint128.
+// 4 bytes IP is stored in the lower 32 bits,
+// 16 bytes one is always converted as is, even if it is an IPv4-mapped IPv6 address.
 func Uint128FromIP(ip net.IP) Uint128 {
-	if v4 := ip.To4(); v4 != nil {
+	if len(ip) == net.IPv4len {
 		return Uint128{
-			low: uint64(binary.BigEndian.Uint32([]byte(v4))),
+			low: uint64(binary.BigEndian.Uint32([]byte(ip))),
 		}
 	}
 
@@ -54,8 +69,8 @@ func (i Uint128) Cmp(j Uint128) int {
 	return 0
 }
 
-// Not exported func should have comment or be unexported
-func (i Uint128) Not(j Uint128) Uint128 {
+// Not returns bitwise complement of i
+func (i Uint128) Not() Uint128 {
 	return Uint128{
 		high: ^i.high,
 		low:  ^i.low,
@@ -70,6 +85,27 @@ func (i Uint128) And(j Uint128) Uint128 {
 	}
 }
 
+// Add returns i+j and true if the sum overflows 128 bits
+func (i Uint128) Add(j Uint128) (Uint128, bool) {
+	low, carry := bits.Add64(i.low, j.low, 0)
+	high, carry := bits.Add64(i.high, j.high, carry)
+
+	return Uint128{high: high, low: low}, carry != 0
+}
+
+// Sub returns i-j and true if j is greater than i
+func (i Uint128) Sub(j Uint128) (Uint128, bool) {
+	low, borrow := bits.Sub64(i.low, j.low, 0)
+	high, borrow := bits.Sub64(i.high, j.high, borrow)
+
+	return Uint128{high: high, low: low}, borrow != 0
+}
+
+// Uint64s returns the high and low 64 bits of i
+func (i Uint128) Uint64s() (high, low uint64) {
+	return i.high, i.low
+}
+
 // LeftShift exported func should have comment or be unexported
 func (i Uint128) LeftShift() Uint128 {
 	j := Uint128{low: i.low << 1}
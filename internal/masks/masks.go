@@ -32,10 +32,13 @@ func Get(ones, bits int) Mask {
 	}
 }
 
+// IPv4 masks have all the 96 upper bits set,
+// so the host part of a mask is the same as for the IPv4-mapped IPv6 address
+// and int128.Uint128 range operations never overflow to the upper bits
 func init() { //nolint: gochecknoinits
 	for ri := range masksV4 {
 		masksV4[ri] = Mask{
-			Mask: int128.Uint128FromIP(net.IP(net.CIDRMask(ri, 32))),
+			Mask: int128.Uint128FromIP(net.IP(net.CIDRMask(96+ri, 128))),
 			Size: bigint.IntByBits(32).SetBit(32 - ri),
 		}
 	}
@@ -0,0 +1,223 @@
+// Package parseerr provides the error type shared by all the parsers of the module
+package parseerr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInputInvalid is matched by every Error with errors.Is
+var ErrInputInvalid = errors.New("invalid input")
+
+// Reason tells what exactly is wrong with the input
+type Reason int
+
+// Reasons available
+const (
+	ReasonSyntax Reason = iota
+	ReasonEmpty
+	ReasonBadAddress
+	ReasonBadOctet
+	ReasonBadPrefixLength
+	ReasonPrefixTooLong
+	ReasonNonCanonical
+	ReasonMixedFamily
+	ReasonReversedRange
+	ReasonMappedAddress
+	ReasonBadCount
+	ReasonCountOverflow
+	ReasonLookup
+	ReasonZone
+)
+
+var reasonNames = []string{ // nolint: gochecknoglobals
+	ReasonSyntax:          "syntax error",
+	ReasonEmpty:           "empty address",
+	ReasonBadAddress:      "bad address",
+	ReasonBadOctet:        "bad IPv4 octet",
+	ReasonBadPrefixLength: "bad prefix length",
+	ReasonPrefixTooLong:   "prefix length too long",
+	ReasonNonCanonical:    "host bits set in CIDR",
+	ReasonMixedFamily:     "mixed address families",
+	ReasonReversedRange:   "range begin is greater than end",
+	ReasonMappedAddress:   "IPv4-mapped IPv6 address",
+	ReasonBadCount:        "bad address count",
+	ReasonCountOverflow:   "address count exceeds the address family",
+	ReasonLookup:          "hostname lookup failed",
+	ReasonZone:            "bad zone identifier",
+}
+
+// String implements fmt.Stringer
+func (r Reason) String() string {
+	if r < 0 || int(r) >= len(reasonNames) {
+		return "Reason(" + strconv.Itoa(int(r)) + ")"
+	}
+
+	return reasonNames[r]
+}
+
+// Family is an address family the input was recognized as
+type Family int
+
+// Families available
+const (
+	FamilyUnknown Family = 0
+	FamilyIPv4    Family = 4
+	FamilyIPv6    Family = 6
+)
+
+// String implements fmt.Stringer
+func (f Family) String() string {
+	switch f {
+	case FamilyIPv4:
+		return "IPv4"
+	case FamilyIPv6:
+		return "IPv6"
+	}
+
+	return "unknown"
+}
+
+// Error describes the input rejected
+// Offset is a byte offset in Input the problem starts at
+// Err, if not nil, is the underlying error
+type Error struct {
+	Input  string
+	Offset int
+	Reason Reason
+	Family Family
+	Err    error
+}
+
+// Error implements error interface
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%q: offset %d: %v: %v", e.Input, e.Offset, ErrInputInvalid, e.Reason)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is makes Error matching ErrInputInvalid
+func (e *Error) Is(target error) bool {
+	return target == ErrInputInvalid
+}
+
+// Shift returns the copy of the error with Input and Offset adjusted
+// to the input containing the original one at the offset provided
+func (e *Error) Shift(input string, offset int) *Error {
+	shifted := *e
+	shifted.Input = input
+	shifted.Offset += offset
+
+	return &shifted
+}
+
+// FamilyOf guesses the family of the address by its notation
+func FamilyOf(addr string) Family {
+	switch {
+	case strings.Contains(addr, ":"):
+		return FamilyIPv6
+	case strings.Contains(addr, "."):
+		return FamilyIPv4
+	}
+
+	return FamilyUnknown
+}
+
+// Addr explains why addr is not a valid IP address
+func Addr(addr string) *Error {
+	family := FamilyOf(addr)
+
+	switch family {
+	case FamilyIPv4:
+		return addrV4(addr)
+	case FamilyIPv6:
+		return addrV6(addr)
+	}
+
+	if addr == "" {
+		return &Error{Input: addr, Reason: ReasonEmpty}
+	}
+
+	return &Error{Input: addr, Reason: ReasonBadAddress}
+}
+
+// CIDR explains why s is not a valid CIDR
+func CIDR(s string) *Error {
+	slash := strings.IndexByte(s, '/')
+	if slash < 0 {
+		return &Error{Input: s, Reason: ReasonSyntax, Family: FamilyOf(s)}
+	}
+
+	addr, prefix := s[:slash], s[slash+1:]
+	family := FamilyOf(addr)
+
+	ones, err := strconv.Atoi(prefix)
+	if err != nil || ones < 0 || strings.TrimLeft(prefix, "0123456789") != "" {
+		return &Error{Input: s, Offset: slash + 1, Reason: ReasonBadPrefixLength, Family: family}
+	}
+
+	if (family == FamilyIPv4 && ones > 32) || ones > 128 {
+		return &Error{Input: s, Offset: slash + 1, Reason: ReasonPrefixTooLong, Family: family}
+	}
+
+	return Addr(addr).Shift(s, 0)
+}
+
+func addrV4(addr string) *Error {
+	octets := strings.Split(addr, ".")
+	offset := 0
+
+	for i, octet := range octets {
+		if i >= 4 {
+			return &Error{Input: addr, Offset: offset - 1, Reason: ReasonBadAddress, Family: FamilyIPv4}
+		}
+
+		if n, err := strconv.Atoi(octet); err != nil || n < 0 || n > 255 || (len(octet) > 1 && octet[0] == '0') ||
+			strings.TrimLeft(octet, "0123456789") != "" {
+			return &Error{Input: addr, Offset: offset, Reason: ReasonBadOctet, Family: FamilyIPv4}
+		}
+
+		offset += len(octet) + 1
+	}
+
+	return &Error{Input: addr, Offset: len(addr), Reason: ReasonBadAddress, Family: FamilyIPv4}
+}
+
+func addrV6(addr string) *Error {
+	offset := 0
+
+	for _, group := range strings.Split(addr, ":") {
+		if strings.Contains(group, ".") {
+			e := addrV4(group).Shift(addr, offset)
+			e.Family = FamilyIPv6
+
+			return e
+		}
+
+		if i := strings.IndexFunc(group, func(r rune) bool { return !isHex(r) }); i >= 0 {
+			return &Error{Input: addr, Offset: offset + i, Reason: ReasonBadAddress, Family: FamilyIPv6}
+		}
+
+		if len(group) > 4 {
+			return &Error{Input: addr, Offset: offset + 4, Reason: ReasonBadAddress, Family: FamilyIPv6}
+		}
+
+		offset += len(group) + 1
+	}
+
+	return &Error{Input: addr, Reason: ReasonBadAddress, Family: FamilyIPv6}
+}
+
+func isHex(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
@@ -0,0 +1,50 @@
+package subnet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrIncorrectStep is returned for the alignment step not dividing the address length or greater than MaxAlignStep
+var ErrIncorrectStep = errors.New("incorrect alignment step")
+
+// MaxAlignStep is the greatest alignment step allowed.
+// Every subnet is expanded into 2^(step-1) children at most, so the step limits the expansion.
+const MaxAlignStep = 16
+
+// Align returns the merged list of Subnet with every subnet of the length not multiple of the family step
+// expanded into the children of the next multiple length, like /22 into four /24s for the step of 8.
+// The list of the merged subnets is maximal, so no aligned subnet could be merged of the children,
+// and the result is the same as merging the siblings only when the result stays aligned.
+// Step 0 or 1 means no alignment for the family, any other step is to divide the address length
+// and not to exceed MaxAlignStep.
+func Align(ips []Subnet, step4, step6 int) ([]Subnet, error) {
+	steps := map[int]int{32: step4, 128: step6}
+
+	for bits, step := range steps {
+		if step < 0 || step > MaxAlignStep || step > 1 && bits%step != 0 {
+			return nil, fmt.Errorf("%d for %d bits: %w", step, bits, ErrIncorrectStep)
+		}
+	}
+
+	var res []Subnet
+
+	for _, s := range ips {
+		step := steps[s.Bits]
+		if step <= 1 || s.Ones%step == 0 {
+			res = append(res, s)
+			continue
+		}
+
+		it, err := s.Split((s.Ones/step + 1) * step)
+		if err != nil {
+			return nil, err
+		}
+
+		for it.Next() {
+			res = append(res, it.Subnet())
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,56 @@
+package subnet
+
+import (
+	"math"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+)
+
+var (
+	closedMask = int128.Uint128FromUint64s(math.MaxUint64, math.MaxUint64) // nolint: gochecknoglobals
+)
+
+// FromRange returns the range from begin to end, inclusive, as a list of subnets, as compact as possible
+// begin is expected to be not greater than end
+func FromRange(begin int128.Uint128, end int128.Uint128, bits int) (res []Subnet) {
+	if begin.Cmp(end) == 0 {
+		return []Subnet{{IP: begin, Bits: bits, Ones: bits}}
+	}
+
+	var (
+		current = begin
+		mask    = closedMask
+	)
+
+	for current.Cmp(end) <= 0 {
+		biggerMask := mask.LeftShift()
+
+		if current.Cmp(current.And(biggerMask)) != 0 {
+			res = append(res, Subnet{IP: current, Bits: bits, Ones: mask.Ones(bits)})
+			current = current.Jump(mask)
+			mask = closedMask
+
+			continue
+		}
+
+		biggerEnd := current.RangeEnd(biggerMask)
+
+		switch biggerEnd.Cmp(end) {
+		case -1:
+			mask = biggerMask
+			continue
+		case 1:
+			res = append(res, Subnet{IP: current, Bits: bits, Ones: mask.Ones(bits)})
+			current = current.Jump(mask)
+			mask = closedMask
+
+			continue
+		}
+
+		res = append(res, Subnet{IP: current, Bits: bits, Ones: biggerMask.Ones(bits)})
+
+		return res
+	}
+
+	return res
+}
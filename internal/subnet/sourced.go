@@ -0,0 +1,105 @@
+package subnet
+
+import (
+	"sort"
+)
+
+// Sourced is a Subnet with the indexes of the input entries it is made of
+type Sourced struct {
+	Subnet
+	Sources []int
+}
+
+// SortSourced sorts the list of Sourced in place and returns it.
+// The order is the same as for Sort, equal subnets keep their relative order.
+func SortSourced(ips []Sourced) []Sourced {
+	sort.SliceStable(ips, func(i, j int) bool { return ips[i].Less(ips[j].Subnet) })
+	return ips
+}
+
+// DedupSortedSourced is DedupSorted keeping the sources:
+// sources of the subnet removed are added to the one including it.
+// dropped, if not nil, is called for every subnet removed with the one including it,
+// the latter is passed as it was in the input, with the sources not extended yet.
+func DedupSortedSourced(ips []Sourced, dropped func(kept, removed Sourced)) []Sourced {
+	if len(ips) == 0 {
+		return ips
+	}
+
+	j := 0
+	kept := ips[j]
+
+	for i := 1; i < len(ips); i++ {
+		if ips[j].Include(ips[i].Subnet) {
+			if dropped != nil {
+				dropped(kept, ips[i])
+			}
+
+			ips[j].Sources = joinSources(ips[j].Sources, ips[i].Sources)
+
+			continue
+		}
+		j++
+
+		ips[j] = ips[i]
+		kept = ips[j]
+	}
+
+	return ips[:j+1]
+}
+
+// MergeSortedSourced is MergeSorted keeping the sources:
+// the subnet merged is made of the sources of both halves.
+func MergeSortedSourced(ips []Sourced) []Sourced {
+	j := -1
+
+	for i := range ips {
+		j++
+		ips[j] = ips[i]
+
+		for j > 0 {
+			bigger, ok := mergeSiblings(ips[j-1].Subnet, ips[j].Subnet)
+			if !ok {
+				break
+			}
+			j--
+
+			ips[j] = Sourced{Subnet: bigger, Sources: joinSources(ips[j].Sources, ips[j+1].Sources)}
+		}
+	}
+
+	return ips[:j+1]
+}
+
+// MergeSourced sorts, de-dups and merges the list of Sourced to the smallest possible form keeping the sources.
+// The list is modified in place.
+func MergeSourced(ips []Sourced) []Sourced {
+	return MergeSortedSourced(DedupSortedSourced(SortSourced(ips), nil))
+}
+
+// joinSources returns the sorted union of the sources, never sharing the memory with the arguments
+func joinSources(a, b []int) []int {
+	res := make([]int, 0, len(a)+len(b))
+	res = append(append(res, a...), b...)
+	sort.Ints(res)
+
+	if len(res) == 0 {
+		return res
+	}
+
+	j := 0
+
+	for i := range res {
+		if i > 0 && res[i] == res[j] {
+			continue
+		}
+
+		if i > 0 {
+			j++
+		}
+
+		res[j] = res[i]
+	}
+
+	return res[:j+1]
+}
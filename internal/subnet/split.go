@@ -0,0 +1,97 @@
+package subnet
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/masks"
+)
+
+// ErrIncorrectLength is returned for the prefix length not fitting the operation
+var ErrIncorrectLength = errors.New("incorrect prefix length")
+
+// Iterator iterates over the child subnets, computing every one on demand,
+// so even 2^64 /128 children of /64 IPv6 subnet are never materialised
+type Iterator struct {
+	current Subnet
+	last    int128.Uint128
+	left    uint64
+	limited bool
+	started bool
+	done    bool
+}
+
+// Next advances the iterator to the next child subnet, returns false if there are no more
+func (it *Iterator) Next() bool {
+	switch {
+	case it.done:
+		return false
+	case it.limited && it.left == 0:
+		it.done = true
+		return false
+	case !it.started:
+		it.started = true
+	case it.current.IP.Cmp(it.last) == 0:
+		it.done = true
+		return false
+	default:
+		it.current.IP = it.current.IP.Jump(it.current.Mask().Mask)
+	}
+
+	if it.limited {
+		it.left--
+	}
+
+	return true
+}
+
+// Subnet returns the current child subnet
+func (it *Iterator) Subnet() Subnet {
+	return it.current
+}
+
+// Split returns the iterator over the subnets of ones length s consists of, in address order
+func (s Subnet) Split(ones int) (*Iterator, error) {
+	if ones < s.Ones || ones > s.Bits {
+		return nil, fmt.Errorf("%s into /%d: %w", s, ones, ErrIncorrectLength)
+	}
+
+	child := Subnet{IP: s.IP, Ones: ones, Bits: s.Bits}
+
+	return &Iterator{
+		current: child,
+		last:    s.IP.RangeEnd(s.Mask().Mask).And(child.Mask().Mask),
+	}, nil
+}
+
+// SplitN returns the iterator over the first n equal subnets of s,
+// the biggest ones s could be split to n of
+func (s Subnet) SplitN(n uint64) (*Iterator, error) {
+	if n == 0 {
+		return nil, fmt.Errorf("%s into 0 parts: %w", s, ErrIncorrectLength)
+	}
+
+	// the number of bits enough to count n children
+	ones := s.Ones + bits.Len64(n-1)
+
+	it, err := s.Split(ones)
+	if err != nil {
+		return nil, fmt.Errorf("%s into %d parts: %w", s, n, ErrIncorrectLength)
+	}
+
+	it.limited = true
+	it.left = n
+
+	return it, nil
+}
+
+// Supernet returns the subnet of ones length including s
+func (s Subnet) Supernet(ones int) (Subnet, error) {
+	if ones < 0 || ones > s.Ones {
+		return s, fmt.Errorf("%s to /%d: %w", s, ones, ErrIncorrectLength)
+	}
+
+	return Subnet{IP: s.IP.And(masks.Get(ones, s.Bits).Mask), Ones: ones, Bits: s.Bits}, nil
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
 
 	"github.com/Djarvur/go-mergeips/internal/int128"
 	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/internal/parseerr"
 )
 
 // Subnet exported type should have comment or be unexported
@@ -18,27 +20,58 @@ type Subnet struct {
 	Bits int
 }
 
-// Include exported func should have comment or be unexported
+// Include reports whether b is the same subnet as s or is fully contained in s.
+// Subnets of different families never include each other.
 func (s Subnet) Include(b Subnet) bool {
-	return s.Bits == b.Bits && s.IP.Cmp(b.IP.And(masks.Get(s.Ones, s.Bits).Mask)) == 0
+	return s.Bits == b.Bits && s.Ones <= b.Ones && s.IP.Cmp(b.IP.And(masks.Get(s.Ones, s.Bits).Mask)) == 0
 }
 
-// String exported func should have comment or be unexported
+// String returns the subnet in CIDR notation.
+// Unlike net.IPNet.String, IPv4-mapped IPv6 subnets stay in IPv6 notation, like ::ffff:10.0.0.1/128.
 func (s Subnet) String() string {
-	return s.IPNet().String()
+	n := s.IPNet()
+
+	if v4 := n.IP.To4(); s.Bits == 128 && v4 != nil {
+		return "::ffff:" + v4.String() + "/" + strconv.Itoa(s.Ones)
+	}
+
+	return n.String()
 }
 
-// FromIPNet exported func should have comment or be unexported
+// FromIPNet converts net.IPNet to Subnet.
+// The family is defined by the mask length, so the IPv4 subnet might have 16 bytes IP,
+// while the IPv4-mapped IPv6 subnet with 16 bytes mask stays IPv6.
+// Host bits of the IP, if any, are cleared.
 func FromIPNet(n *net.IPNet) Subnet {
 	ones, bits := n.Mask.Size()
 
+	ip := n.IP.To16()
+	if v4 := n.IP.To4(); bits == 32 && v4 != nil {
+		ip = v4
+	}
+
 	return Subnet{
-		IP:   int128.Uint128FromIP(n.IP),
+		IP:   int128.Uint128FromIP(ip).And(masks.Get(ones, bits).Mask),
 		Ones: ones,
 		Bits: bits,
 	}
 }
 
+// FromIPNets converts the list of net.IPNet to the list of Subnet
+func FromIPNets(nets []*net.IPNet) []Subnet {
+	if nets == nil {
+		return nil
+	}
+
+	res := make([]Subnet, 0, len(nets))
+
+	for _, n := range nets {
+		res = append(res, FromIPNet(n))
+	}
+
+	return res
+}
+
 // IPNet exported func should have comment or be unexported
 func (s Subnet) IPNet() *net.IPNet {
 	return &net.IPNet{
@@ -47,13 +80,31 @@ func (s Subnet) IPNet() *net.IPNet {
 	}
 }
 
-// Sort exported func should have comment or be unexported
+// IPNets converts the list of Subnet to the list of net.IPNet
+func IPNets(ips []Subnet) []*net.IPNet {
+	if ips == nil {
+		return nil
+	}
+
+	res := make([]*net.IPNet, 0, len(ips))
+
+	for _, s := range ips {
+		res = append(res, s.IPNet())
+	}
+
+	return res
+}
+
+// Sort sorts the list of Subnet in place and returns it.
+// See Less for the order.
 func Sort(ips []Subnet) []Subnet {
 	sort.Slice(ips, func(i, j int) bool { return ips[i].Less(ips[j]) })
 	return ips
 }
 
-// Less exported func should have comment or be unexported
+// Less defines the order all the merge functions rely on:
+// IPv4 goes first, then subnets are ordered by the first address,
+// bigger subnet goes first if the first addresses are equal.
 func (s Subnet) Less(b Subnet) bool {
 	if s.Bits != b.Bits {
 		return s.Bits < b.Bits
@@ -71,8 +122,12 @@ func (s Subnet) Mask() masks.Mask {
 	return masks.Get(s.Ones, s.Bits)
 }
 
-// DedupSorted exported func should have comment or be unexported
+// DedupSorted removes all the identical or included-in-bigger-one-presented subnets from the sorted list
 func DedupSorted(ips []Subnet) []Subnet {
+	if len(ips) == 0 {
+		return ips
+	}
+
 	j := 0
 
 	for i := 1; i < len(ips); i++ {
@@ -87,13 +142,17 @@ func DedupSorted(ips []Subnet) []Subnet {
 	return ips[:j+1]
 }
 
-// MergePairs exported func should have comment or be unexported
+// MergePairs merges all the adjacent pairs of sibling subnets in the sorted and de-duped list.
+// One pass only, so the result might be mergeable further.
 func MergePairs(ips []Subnet) []Subnet {
+	if len(ips) == 0 {
+		return ips
+	}
+
 	j := 0
 
 	for i := 1; i < len(ips); i++ {
-		bigger, ok := biggerSubnet(ips[j])
-		if ok && ips[j].Ones == ips[i].Ones && bigger.Include(ips[i]) {
+		if bigger, ok := mergeSiblings(ips[j], ips[i]); ok {
 			ips[j] = bigger
 			continue
 		}
@@ -105,8 +164,8 @@ func MergePairs(ips []Subnet) []Subnet {
 	return ips[:j+1]
 }
 
-// MergeSorted exported func should have comment or be unexported
-func MergeSorted(ips []Subnet) []Subnet {
+// MergeSortedByRepeat is repeating MergePairs as long as it does merge anything
+func MergeSortedByRepeat(ips []Subnet) []Subnet {
 	for newips := MergePairs(ips); len(newips) != len(ips); newips = MergePairs(ips) {
 		ips = newips
 	}
@@ -114,14 +173,47 @@ func MergeSorted(ips []Subnet) []Subnet {
 	return ips
 }
 
-// Merge exported func should have comment or be unexported
+// MergeSorted merges the sorted and de-duped list of Subnet to the smallest possible form in a single pass.
+// The list is modified in place.
+func MergeSorted(ips []Subnet) []Subnet {
+	j := -1
+
+	for i := range ips {
+		j++
+		ips[j] = ips[i]
+
+		for j > 0 {
+			bigger, ok := mergeSiblings(ips[j-1], ips[j])
+			if !ok {
+				break
+			}
+			j--
+
+			ips[j] = bigger
+		}
+	}
+
+	return ips[:j+1]
+}
+
+// Merge sorts, de-dups and merges the list of Subnet to the smallest possible form.
+// The list is modified in place.
 func Merge(ips []Subnet) []Subnet {
-	ips = DedupSorted(Sort(ips))
-	for newips := MergePairs(ips); len(newips) != len(ips); newips = MergePairs(ips) {
-		ips = newips
+	return MergeSorted(DedupSorted(Sort(ips)))
+}
+
+// mergeSiblings returns the parent subnet if a and b are the two halves of it.
+func mergeSiblings(a, b Subnet) (Subnet, bool) {
+	if a.Bits != b.Bits || a.Ones != b.Ones || a.Ones == 0 {
+		return a, false
 	}
 
-	return ips
+	bigger, ok := biggerSubnet(a)
+	if !ok || !bigger.Include(b) {
+		return a, false
+	}
+
+	return bigger, true
 }
 
 func biggerSubnet(s Subnet) (Subnet, bool) {
@@ -141,15 +233,25 @@ func biggerSubnet(s Subnet) (Subnet, bool) {
 // ErrIncorrectCIDR exported var should have comment or be unexported
 var ErrIncorrectCIDR = errors.New("CIDR definition incorrect")
 
-// ParseCIDR exported func should have comment or be unexported
+// ParseCIDR parses CIDR notation subnet
+// If strict is true CIDR defined with not-a-first address in the subnet is an error.
+// All the errors returned are *parseerr.Error
 func ParseCIDR(s string, strict bool) (Subnet, error) {
 	ip, n, err := net.ParseCIDR(s)
 	if err != nil {
-		return Subnet{}, err
+		e := parseerr.CIDR(s)
+		e.Err = err
+
+		return Subnet{}, e
 	}
 
 	if strict && !ip.Equal(n.IP) {
-		return Subnet{}, fmt.Errorf("%q expected, got %q: %w", n.String(), s, ErrIncorrectCIDR)
+		return Subnet{}, &parseerr.Error{
+			Input:  s,
+			Reason: parseerr.ReasonNonCanonical,
+			Family: parseerr.FamilyOf(s),
+			Err:    fmt.Errorf("%q expected: %w", n.String(), ErrIncorrectCIDR),
+		}
 	}
 
 	return FromIPNet(n), nil
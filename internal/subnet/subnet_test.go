@@ -2,10 +2,13 @@
 package subnet_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/go-test/deep"
 
+	"github.com/Djarvur/go-mergeips/internal/parseerr"
 	"github.com/Djarvur/go-mergeips/internal/subnet"
 )
 
@@ -59,3 +62,63 @@ func TestMerge(t *testing.T) {
 		}
 	}
 }
+
+func TestParseCIDRError(t *testing.T) {
+	_, err := subnet.ParseCIDR("10.0.0.5/24", true)
+
+	var parseErr *parseerr.Error
+	if !errors.As(err, &parseErr) || parseErr.Reason != parseerr.ReasonNonCanonical {
+		t.Fatalf("got %v, expected non-canonical *parseerr.Error", err)
+	}
+
+	if !errors.Is(err, subnet.ErrIncorrectCIDR) || !errors.Is(err, parseerr.ErrInputInvalid) {
+		t.Errorf("got %v, expected to match %v and %v", err, subnet.ErrIncorrectCIDR, parseerr.ErrInputInvalid)
+	}
+
+	_, err = subnet.ParseCIDR("10.0.0.0/40", false)
+	if !errors.As(err, &parseErr) || parseErr.Reason != parseerr.ReasonPrefixTooLong || parseErr.Offset != 9 {
+		t.Errorf("got %v, expected prefix too long at offset 9", err)
+	}
+}
+
+func TestMergeSourced(t *testing.T) {
+	in := []subnet.Sourced{
+		{Subnet: subnet.MustParseCIDR("10.0.0.128/25", true), Sources: []int{0}},
+		{Subnet: subnet.MustParseCIDR("10.0.1.0/24", true), Sources: []int{1}},
+		{Subnet: subnet.MustParseCIDR("10.0.0.0/24", true), Sources: []int{2}},
+		{Subnet: subnet.MustParseCIDR("10.0.3.0/24", true), Sources: []int{3}},
+		{Subnet: subnet.MustParseCIDR("10.0.3.0/24", true), Sources: []int{4}},
+	}
+	expected := []subnet.Sourced{
+		{Subnet: subnet.MustParseCIDR("10.0.0.0/23", true), Sources: []int{0, 1, 2}},
+		{Subnet: subnet.MustParseCIDR("10.0.3.0/24", true), Sources: []int{3, 4}},
+	}
+
+	var dropped [][2]int
+
+	merged := subnet.MergeSortedSourced(subnet.DedupSortedSourced(
+		subnet.SortSourced(in),
+		func(kept, removed subnet.Sourced) {
+			dropped = append(dropped, [2]int{kept.Sources[0], removed.Sources[0]})
+		},
+	))
+
+	if diff := deep.Equal(sourcedStrings(merged), sourcedStrings(expected)); diff != nil {
+		t.Errorf("got %v, expected %v: %v", merged, expected, diff)
+	}
+
+	if diff := deep.Equal(dropped, [][2]int{{2, 0}, {3, 4}}); diff != nil {
+		t.Errorf("got dropped %v: %v", dropped, diff)
+	}
+}
+
+// sourcedStrings returns the list as strings, deep.Equal does not compare Subnet.IP having unexported fields only
+func sourcedStrings(list []subnet.Sourced) []string {
+	res := make([]string, 0, len(list))
+
+	for _, s := range list {
+		res = append(res, fmt.Sprintf("%s %v", s.Subnet, s.Sources))
+	}
+
+	return res
+}
@@ -1,142 +1,119 @@
 // Package ipnet provides some useful methods to handle net.IPNet lists
+// All the functions are thin wrappers around the same merge engine,
+// so the results are identical to ones of mergeips.Merge
 package ipnet
 
 import (
-	"bytes"
-	"fmt"
 	"net"
 	"sort"
 
-	"github.com/Djarvur/go-mergeips/internal/bigint"
-	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
 )
 
-// MergeByRepeat is a wrapper around MergeSorted
+// MergeByRepeat is a wrapper around MergeSortedByRepeat
 func MergeByRepeat(nets []*net.IPNet) []*net.IPNet {
 	return MergeSortedByRepeat(DedupSorted(Sort(nets)))
 }
 
-// MergePairs merges all the suitable pairs of subnets in the net.IPNet list
+// MergePairs merges all the suitable pairs of subnets in the sorted and de-duped net.IPNet list
+// One pass only, so the result might be mergeable further
 func MergePairs(nets []*net.IPNet) []*net.IPNet {
-	j := 0
-
-	for i := 1; i < len(nets); i++ {
-		bigger, _ := biggerIPNet(nets[j])
-		if bigger != nil && bigger.Contains(nets[i].IP) && bytes.Equal(nets[j].Mask, nets[i].Mask) {
-			nets[j] = bigger
-			continue
-		}
-		j++
-
-		nets[j] = nets[i]
-	}
-
-	return nets[:j+1]
+	return apply(nets, subnet.MergePairs)
 }
 
 // MergeSortedByRepeat is repeating MergePairs as long as it does merge anything
 func MergeSortedByRepeat(nets []*net.IPNet) []*net.IPNet {
-	for newnets := MergePairs(nets); len(newnets) != len(nets); newnets = MergePairs(nets) {
-		nets = newnets
-	}
-
-	return nets
+	return apply(nets, subnet.MergeSortedByRepeat)
 }
 
 // MergeSorted is merging previously sorted and de-duped list of net.IPNet to the smallest possible form
 func MergeSorted(nets []*net.IPNet) []*net.IPNet {
-	return mergeSortedBig(nets)
+	return apply(nets, subnet.MergeSorted)
 }
 
-func mergeSortedBig(nets []*net.IPNet) []*net.IPNet {
-	panic("does not work")
-	if len(nets) == 0 {
-		return nets
-	}
-
-	doneUpTo := 0
-	bigger, toFill := biggerIPNet(nets[doneUpTo])
-	fullyConsumed := 0
-
-	for lastChecked := 1; lastChecked < len(nets); lastChecked++ {
-		if bigger != nil && bigger.Contains(nets[lastChecked].IP) {
-			toFill = toFill.Sub(masks.Get(nets[lastChecked].Mask.Size()).Size)
-			if toFill.IsZero() {
-				fmt.Printf("%v %v %v %v %v %v\n", doneUpTo, fullyConsumed, lastChecked, nets[doneUpTo], nets[lastChecked], bigger)
-				nets[doneUpTo] = bigger
-				bigger, toFill = biggerIPNet(nets[doneUpTo])
-				fullyConsumed = lastChecked
-			}
-
-			continue
-		}
-
-		if fullyConsumed != lastChecked {
-			lastChecked = fullyConsumed
-		}
-
-		doneUpTo++
-		nets[doneUpTo] = nets[fullyConsumed]
-		bigger, toFill = biggerIPNet(nets[doneUpTo])
-	}
-
-	if fullyConsumed < len(nets) {
-		return append(nets[:doneUpTo+1], nets[fullyConsumed:]...)
+// MergeSortedAligned is MergeSorted with the prefix lengths multiples of the family steps only,
+// the unaligned prefixes expanded into the aligned children, see mergeips.MergeAligned.
+// Step 0 or 1 means no alignment, any other step is to divide the address length and not to exceed 16,
+// ErrIncorrectStep is returned otherwise.
+// The list is not modified, as the result might be longer.
+func MergeSortedAligned(nets []*net.IPNet, step4, step6 int) ([]*net.IPNet, error) {
+	aligned, err := subnet.Align(subnet.MergeSorted(subnet.FromIPNets(nets)), step4, step6)
+	if err != nil {
+		return nil, err
 	}
 
-	return nets[:doneUpTo+1]
+	return subnet.IPNets(aligned), nil
 }
 
-func biggerIPNet(n *net.IPNet) (*net.IPNet, bigint.Int) {
-	ones, bits := n.Mask.Size()
-	if ones == 0 {
-		return n, masks.Get(ones, bits).Size
-	}
-
-	biggerMask := net.CIDRMask(ones-1, bits)
-	if !n.IP.Equal(n.IP.Mask(biggerMask)) {
-		return nil, masks.Get(bits, bits).Size
-	}
-
-	return &net.IPNet{IP: n.IP, Mask: biggerMask}, masks.Get(ones, bits).Size
-}
-
-// Sort sorts lust of net.IPNet and return it
-// IPv4 goes first, bigger mask goes first
+// Sort sorts list of net.IPNet in place and return it
+// IPv4 goes first, then lower address goes first, bigger subnet goes first for the same address
 func Sort(nets []*net.IPNet) []*net.IPNet {
-	sort.Slice(nets, func(i, j int) bool { return Less(nets[i], nets[j]) })
+	sort.Sort(sortable{nets: nets, keys: subnet.FromIPNets(nets)})
 	return nets
 }
 
 // Less is comparing to net.IPNet
 // To be used with Sort()
 func Less(a, b *net.IPNet) bool {
-	if cmp := bytes.Compare(a.IP, b.IP); cmp != 0 {
-		return cmp < 0
-	}
-
-	aOnes, _ := a.Mask.Size()
-	bOnes, _ := b.Mask.Size()
-
-	return aOnes < bOnes
+	return subnet.FromIPNet(a).Less(subnet.FromIPNet(b))
 }
 
-// DedupSorted removes all the identical or included-in-bigger-one-presented sublens from the list
+// DedupSorted removes all the identical or included-in-bigger-one-presented sublens from the sorted list
 func DedupSorted(nets []*net.IPNet) []*net.IPNet {
+	return DedupSortedReport(nets, nil)
+}
+
+// DedupSortedReport is DedupSorted reporting the subnets removed:
+// dropped, if not nil, is called for every subnet removed with the one including it
+func DedupSortedReport(nets []*net.IPNet, dropped func(kept, removed *net.IPNet)) []*net.IPNet {
 	if len(nets) == 0 {
 		return nets
 	}
 
+	keys := subnet.FromIPNets(nets)
 	j := 0
 
 	for i := 1; i < len(nets); i++ {
-		if nets[j].Contains(nets[i].IP) {
+		if keys[j].Include(keys[i]) {
+			if dropped != nil {
+				dropped(nets[j], nets[i])
+			}
+
 			continue
 		}
 		j++
 
+		keys[j] = keys[i]
 		nets[j] = nets[i]
 	}
 
 	return nets[:j+1]
 }
+
+// apply runs f over the list converted to subnet.Subnet
+// and stores the result back to the beginning of the list
+func apply(nets []*net.IPNet, f func([]subnet.Subnet) []subnet.Subnet) []*net.IPNet {
+	if len(nets) == 0 {
+		return nets
+	}
+
+	res := f(subnet.FromIPNets(nets))
+
+	for i, s := range res {
+		nets[i] = s.IPNet()
+	}
+
+	return nets[:len(res)]
+}
+
+type sortable struct {
+	nets []*net.IPNet
+	keys []subnet.Subnet
+}
+
+func (s sortable) Len() int           { return len(s.nets) }
+func (s sortable) Less(i, j int) bool { return s.keys[i].Less(s.keys[j]) }
+func (s sortable) Swap(i, j int) {
+	s.nets[i], s.nets[j] = s.nets[j], s.nets[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
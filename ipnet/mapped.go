@@ -0,0 +1,100 @@
+package ipnet
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Errors
+var (
+	ErrIncorrectIP     = errors.New("incorrect IP")
+	ErrMappedAddress   = errors.New("IPv4-mapped IPv6 address")
+	ErrIncorrectSubnet = errors.New("incorrect subnet")
+	ErrIncorrectStep   = subnet.ErrIncorrectStep
+)
+
+// MappedPolicy defines how IPv4-mapped IPv6 addresses, like ::ffff:10.0.0.1, are handled
+// 4 bytes net.IP is always IPv4, and 16 bytes one is IPv6 unless the policy says otherwise
+// Note net.ParseIP returns 16 bytes for IPv4 addresses, so the IPv4 ones have to be converted
+// with net.IP.To4() before being passed to the MappedKeep or MappedReject policy
+type MappedPolicy int
+
+// Policies available
+const (
+	// MappedUnmap converts IPv4-mapped IPv6 addresses to IPv4 ones, the same way net package does
+	MappedUnmap MappedPolicy = iota
+	// MappedKeep keeps IPv4-mapped IPv6 addresses as IPv6 ones, so they never merge with IPv4 ones
+	MappedKeep
+	// MappedReject returns ErrMappedAddress for IPv4-mapped IPv6 addresses
+	MappedReject
+)
+
+// IP returns IP converted according to the policy: 4 bytes for IPv4, 16 bytes for IPv6
+func (p MappedPolicy) IP(ip net.IP) (net.IP, error) {
+	switch {
+	case len(ip) == net.IPv4len:
+		return ip, nil
+	case len(ip) != net.IPv6len:
+		return nil, fmt.Errorf("%v: %w", []byte(ip), ErrIncorrectIP)
+	case ip.To4() == nil:
+		return ip, nil
+	}
+
+	switch p {
+	case MappedUnmap:
+		return ip.To4(), nil
+	case MappedKeep:
+		return ip, nil
+	}
+
+	return nil, fmt.Errorf("::ffff:%s: %w", ip.To4(), ErrMappedAddress)
+}
+
+// IPNet returns subnet converted according to the policy: 4 bytes IP and mask for IPv4, 16 bytes for IPv6
+// The family is defined by the mask length, the subnet is IPv4-mapped if it is inside ::ffff:0:0/96
+func (p MappedPolicy) IPNet(n *net.IPNet) (*net.IPNet, error) {
+	ones, bits := n.Mask.Size()
+
+	switch {
+	case bits == 32 && n.IP.To4() != nil:
+		return &net.IPNet{IP: n.IP.To4(), Mask: n.Mask}, nil
+	case bits != 128 || len(n.IP) != net.IPv6len:
+		return nil, fmt.Errorf("%v: %w", n, ErrIncorrectSubnet)
+	case ones < 96 || n.IP.To4() == nil:
+		return n, nil
+	}
+
+	switch p {
+	case MappedUnmap:
+		return &net.IPNet{IP: n.IP.To4(), Mask: net.CIDRMask(ones-96, 32)}, nil
+	case MappedKeep:
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("::ffff:%s/%d: %w", n.IP.To4(), ones, ErrMappedAddress)
+}
+
+// Sort converts every subnet in the list according to the policy and sorts the list
+// The list is modified in place
+func (p MappedPolicy) Sort(nets []*net.IPNet) ([]*net.IPNet, error) {
+	for i, n := range nets {
+		converted, err := p.IPNet(n)
+		if err != nil {
+			return nil, err
+		}
+
+		nets[i] = converted
+	}
+
+	return Sort(nets), nil
+}
+
+// String returns the subnet in CIDR notation, the family defined by the mask length.
+// Unlike net.IPNet.String, IPv4-mapped IPv6 subnets kept with MappedKeep are written in IPv6 notation,
+// like ::ffff:10.0.0.1/128, so they can be told from IPv4 ones.
+func String(n *net.IPNet) string {
+	return subnet.FromIPNet(n).String()
+}
@@ -0,0 +1,116 @@
+package ipnet_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips/ipnet"
+	"github.com/go-test/deep"
+)
+
+type testMappedRow struct {
+	in       []*net.IPNet
+	policy   ipnet.MappedPolicy
+	expected []*net.IPNet
+	err      error
+}
+
+var testMappedData = []testMappedRow{
+	{
+		in: []*net.IPNet{
+			parseCIDR("::ffff:10.0.0.0/120"),
+			{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(25, 32)},
+			parseCIDR("9.0.0.0/8"),
+		},
+		policy: ipnet.MappedUnmap,
+		expected: []*net.IPNet{
+			{IP: net.IPv4(9, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+			{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(24, 32)},
+			{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(25, 32)},
+		},
+	},
+	{
+		in: []*net.IPNet{
+			parseCIDR("::ffff:10.0.0.0/120"),
+			{IP: net.ParseIP("10.0.0.0"), Mask: net.CIDRMask(25, 32)},
+			parseCIDR("9.0.0.0/8"),
+		},
+		policy: ipnet.MappedKeep,
+		expected: []*net.IPNet{
+			{IP: net.IPv4(9, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+			{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(25, 32)},
+			{IP: net.ParseIP("::ffff:10.0.0.0"), Mask: net.CIDRMask(120, 128)},
+		},
+	},
+	{
+		in: []*net.IPNet{
+			parseCIDR("::/0"),
+			parseCIDR("9.0.0.0/8"),
+		},
+		policy: ipnet.MappedReject,
+		expected: []*net.IPNet{
+			parseCIDR("9.0.0.0/8"),
+			parseCIDR("::/0"),
+		},
+	},
+	{
+		in: []*net.IPNet{
+			parseCIDR("::ffff:10.0.0.0/120"),
+			parseCIDR("9.0.0.0/8"),
+		},
+		policy: ipnet.MappedReject,
+		err:    ipnet.ErrMappedAddress,
+	},
+}
+
+func TestMappedSort(t *testing.T) {
+	for _, row := range testMappedData {
+		out, err := row.policy.Sort(row.in)
+		if !errors.Is(err, row.err) {
+			t.Errorf("got error %v, expected %v", err, row.err)
+		}
+
+		if diff := deep.Equal(out, row.expected); diff != nil {
+			t.Errorf("got %v, expected %v: %v", out, row.expected, diff)
+		}
+	}
+}
+
+func TestMappedIP(t *testing.T) {
+	ip := net.ParseIP("::ffff:10.0.0.1")
+
+	if out, err := ipnet.MappedUnmap.IP(ip); err != nil || len(out) != net.IPv4len {
+		t.Errorf("unmap: got %v %v, expected 4 bytes IP", []byte(out), err)
+	}
+
+	if out, err := ipnet.MappedKeep.IP(ip); err != nil || len(out) != net.IPv6len {
+		t.Errorf("keep: got %v %v, expected 16 bytes IP", []byte(out), err)
+	}
+
+	if _, err := ipnet.MappedReject.IP(ip); !errors.Is(err, ipnet.ErrMappedAddress) {
+		t.Errorf("reject: got %v, expected %v", err, ipnet.ErrMappedAddress)
+	}
+
+	if out, err := ipnet.MappedReject.IP(ip.To4()); err != nil || len(out) != net.IPv4len {
+		t.Errorf("reject: got %v %v, expected 4 bytes IP", []byte(out), err)
+	}
+}
+
+func TestString(t *testing.T) {
+	for in, expected := range map[string]string{
+		"10.0.0.0/8":          "10.0.0.0/8",
+		"::ffff:10.0.0.1/128": "::ffff:10.0.0.1/128",
+		"::ffff:0.0.0.0/96":   "::ffff:0.0.0.0/96",
+		"::/0":                "::/0",
+		"2001:db8::/32":       "2001:db8::/32",
+	} {
+		if got := ipnet.String(parseCIDR(in)); got != expected {
+			t.Errorf("%s: got %s, expected %s", in, got, expected)
+		}
+	}
+
+	if got := ipnet.String(&net.IPNet{IP: net.ParseIP("10.0.0.1"), Mask: net.CIDRMask(32, 32)}); got != "10.0.0.1/32" {
+		t.Errorf("got %s, expected 10.0.0.1/32", got)
+	}
+}
@@ -1,6 +1,7 @@
 package ipnet_test
 
 import (
+	"errors"
 	"net"
 	"testing"
 
@@ -59,3 +60,27 @@ func TestMergeByRepeat(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeSortedAligned(t *testing.T) {
+	in := []*net.IPNet{parseCIDR("10.0.0.0/23"), parseCIDR("10.0.2.0/24"), parseCIDR("2001:db8::/31")}
+	expected := []*net.IPNet{
+		parseCIDR("10.0.0.0/24"),
+		parseCIDR("10.0.1.0/24"),
+		parseCIDR("10.0.2.0/24"),
+		parseCIDR("2001:db8::/32"),
+		parseCIDR("2001:db9::/32"),
+	}
+
+	out, err := ipnet.MergeSortedAligned(in, 8, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(out, expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", out, expected, diff)
+	}
+
+	if _, err = ipnet.MergeSortedAligned(in, 0, 64); !errors.Is(err, ipnet.ErrIncorrectStep) {
+		t.Errorf("got error %v, expected %v", err, ipnet.ErrIncorrectStep)
+	}
+}
@@ -101,6 +101,36 @@ func TestDedup(t *testing.T) {
 	}
 }
 
+func TestDedupReport(t *testing.T) {
+	in := []*net.IPNet{
+		parseCIDR("192.168.0.0/30"),
+		parseCIDR("192.168.0.0/31"),
+		parseCIDR("192.168.0.3/32"),
+		parseCIDR("192.168.0.8/30"),
+		parseCIDR("192.168.0.8/30"),
+	}
+
+	var dropped [][2]string
+
+	out := ipnet.DedupSortedReport(in, func(kept, removed *net.IPNet) {
+		dropped = append(dropped, [2]string{removed.String(), kept.String()})
+	})
+
+	expected := [][2]string{
+		{"192.168.0.0/31", "192.168.0.0/30"},
+		{"192.168.0.3/32", "192.168.0.0/30"},
+		{"192.168.0.8/30", "192.168.0.8/30"},
+	}
+
+	if diff := deep.Equal(dropped, expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", dropped, expected, diff)
+	}
+
+	if diff := deep.Equal(out, []*net.IPNet{parseCIDR("192.168.0.0/30"), parseCIDR("192.168.0.8/30")}); diff != nil {
+		t.Errorf("got %v: %v", out, diff)
+	}
+}
+
 func parseCIDR(s string) *net.IPNet {
 	_, n, err := net.ParseCIDR(s)
 	if err != nil {
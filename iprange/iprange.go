@@ -5,81 +5,96 @@ package iprange
 import (
 	"errors"
 	"fmt"
-	"math"
 	"net"
 
 	"github.com/Djarvur/go-mergeips/internal/int128"
 	"github.com/Djarvur/go-mergeips/internal/subnet"
+	"github.com/Djarvur/go-mergeips/ipnet"
 )
 
 // Errors
 var (
 	ErrIncorrectRange = errors.New("incorrect range")
+	ErrMixedFamily    = errors.New("range begin and end are of different families")
+	ErrReversedRange  = errors.New("range begin is greater than end")
+	ErrIncorrectStep  = subnet.ErrIncorrectStep
 )
 
-var (
-	closedMask = int128.Uint128FromUint64s(math.MaxUint64, math.MaxUint64) // nolint: gochecknoglobals
-)
-
-// Merge returns a range as a list of subnets, as compact as possible
-func Merge(begin net.IP, end net.IP) []*net.IPNet {
-	bits := 128
-	if begin.To4() != nil {
-		bits = 32
-	}
-
-	res128 := mergeRange128(int128.Uint128FromIP(begin), int128.Uint128FromIP(end), bits)
-	res := make([]*net.IPNet, 0, len(res128))
+// RangeError is returned for the ranges could not be merged
+// Matches ErrIncorrectRange and the precise reason, ErrMixedFamily or ErrReversedRange, with errors.Is
+type RangeError struct {
+	Begin net.IP
+	End   net.IP
+	Err   error
+}
 
-	for _, n := range res128 {
-		res = append(res, n.IPNet())
-	}
+// Error implements error interface
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("%s-%s: %v", e.Begin, e.End, e.Err)
+}
 
-	return res
+// Unwrap returns the precise reason
+func (e *RangeError) Unwrap() error {
+	return e.Err
 }
 
-func mergeRange128(begin int128.Uint128, end int128.Uint128, bits int) (res []subnet.Subnet) {
-	switch begin.Cmp(end) {
-	case 1:
-		panic(fmt.Errorf("%s-%s: %w", begin.IP(bits).String(), end.IP(bits).String(), ErrIncorrectRange))
-	case 0:
-		return []subnet.Subnet{{IP: begin, Bits: bits, Ones: bits}}
-	}
+// Is makes RangeError matching ErrIncorrectRange
+func (e *RangeError) Is(target error) bool {
+	return target == ErrIncorrectRange
+}
 
-	var (
-		current = begin
-		mask    = closedMask
-	)
+// Options defines the way ranges are handled
+// Zero value is ready to use
+type Options struct {
+	// Mapped defines how IPv4-mapped IPv6 addresses are handled
+	Mapped ipnet.MappedPolicy
+	// SwapReversed makes the range with begin greater than end handled as end-begin one
+	// instead of returning ErrReversedRange
+	SwapReversed bool
+	// Align4 and Align6 are the steps the prefix lengths are to be multiples of, per family,
+	// like 8 and 4 for reverse DNS zones, 0 for no alignment, see ipnet.MergeSortedAligned
+	Align4 int
+	Align6 int
+}
 
-	for current.Cmp(end) <= 0 {
-		biggerMask := mask.LeftShift()
+// Merge returns a range as a list of subnets, as compact as possible
+// IPv4-mapped IPv6 addresses are treated as IPv4 ones
+func Merge(begin net.IP, end net.IP) ([]*net.IPNet, error) {
+	return Options{}.Merge(begin, end)
+}
 
-		if current.Cmp(current.And(biggerMask)) != 0 {
-			res = append(res, subnet.Subnet{IP: current, Bits: bits, Ones: mask.Ones(bits)})
-			current = current.Jump(mask)
-			mask = closedMask
+// Merge returns a range as a list of subnets, as compact as possible
+// *RangeError is returned if begin and end are of different families or begin is greater than end,
+// ErrIncorrectStep for the alignment step not allowed
+func (o Options) Merge(begin net.IP, end net.IP) ([]*net.IPNet, error) {
+	begin, err := o.Mapped.IP(begin)
+	if err != nil {
+		return nil, err
+	}
 
-			continue
-		}
+	end, err = o.Mapped.IP(end)
+	if err != nil {
+		return nil, err
+	}
 
-		biggerEnd := current.RangeEnd(biggerMask)
+	if len(begin) != len(end) {
+		return nil, &RangeError{Begin: begin, End: end, Err: ErrMixedFamily}
+	}
 
-		switch biggerEnd.Cmp(end) {
-		case -1:
-			mask = biggerMask
-			continue
-		case 1:
-			res = append(res, subnet.Subnet{IP: current, Bits: bits, Ones: mask.Ones(bits)})
-			current = current.Jump(mask)
-			mask = closedMask
+	begin128, end128 := int128.Uint128FromIP(begin), int128.Uint128FromIP(end)
 
-			continue
+	if begin128.Cmp(end128) > 0 {
+		if !o.SwapReversed {
+			return nil, &RangeError{Begin: begin, End: end, Err: ErrReversedRange}
 		}
 
-		res = append(res, subnet.Subnet{IP: current, Bits: bits, Ones: biggerMask.Ones(bits)})
+		begin128, end128 = end128, begin128
+	}
 
-		return res
+	res, err := subnet.Align(subnet.FromRange(begin128, end128, len(begin)*8), o.Align4, o.Align6)
+	if err != nil {
+		return nil, err
 	}
 
-	return res
+	return subnet.IPNets(res), nil
 }
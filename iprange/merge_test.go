@@ -1,9 +1,11 @@
 package iprange_test
 
 import (
+	"errors"
 	"net"
 	"testing"
 
+	"github.com/Djarvur/go-mergeips/ipnet"
 	"github.com/Djarvur/go-mergeips/iprange"
 	"github.com/go-test/deep"
 )
@@ -46,17 +48,106 @@ var testTableMergeRangeV4 = []testRowMergeRange{
 
 func TestMergeRange(t *testing.T) {
 	for _, r := range testTableMergeRangeV4 {
-		subnets := iprange.Merge(r.begin, r.end)
+		subnets, err := iprange.Merge(r.begin, r.end)
+		if err != nil {
+			t.Errorf("%s-%s: %v", r.begin, r.end, err)
+		}
+
+		if diff := deep.Equal(subnets, r.expected); diff != nil {
+			t.Errorf("got %v, expected %v: %v", subnets, r.expected, diff)
+		}
+	}
+}
+
+type testRowMergeOptions struct {
+	begin    net.IP
+	end      net.IP
+	options  iprange.Options
+	expected []*net.IPNet
+	err      error
+}
+
+var testTableMergeOptions = []testRowMergeOptions{
+	{
+		begin: net.ParseIP("::ffff:10.0.0.0"), end: net.ParseIP("10.0.0.3").To4(), options: iprange.Options{Mapped: ipnet.MappedUnmap},
+		expected: []*net.IPNet{parseCIDR("10.0.0.0/30")},
+	},
+	{
+		begin: net.ParseIP("::ffff:10.0.0.0"), end: net.ParseIP("::ffff:10.0.0.3"), options: iprange.Options{Mapped: ipnet.MappedKeep},
+		expected: []*net.IPNet{{IP: net.ParseIP("::ffff:10.0.0.0"), Mask: net.CIDRMask(126, 128)}},
+	},
+	{
+		begin: net.ParseIP("::ffff:10.0.0.0"), end: net.ParseIP("10.0.0.3").To4(), options: iprange.Options{Mapped: ipnet.MappedKeep},
+		err: iprange.ErrIncorrectRange,
+	},
+	{
+		begin: net.ParseIP("::ffff:10.0.0.0"), end: net.ParseIP("::ffff:10.0.0.3"), options: iprange.Options{Mapped: ipnet.MappedReject},
+		err: ipnet.ErrMappedAddress,
+	},
+	{
+		begin: net.ParseIP("0.0.0.0").To4(), end: net.ParseIP("::ffff"),
+		err: iprange.ErrMixedFamily,
+	},
+	{
+		begin: net.ParseIP("10.0.0.3"), end: net.ParseIP("10.0.0.0"),
+		err: iprange.ErrReversedRange,
+	},
+	{
+		begin: net.ParseIP("10.0.0.3"), end: net.ParseIP("10.0.0.0"), options: iprange.Options{SwapReversed: true},
+		expected: []*net.IPNet{parseCIDR("10.0.0.0/30")},
+	},
+	{
+		begin: net.ParseIP("2001:db8::ff"), end: net.ParseIP("2001:db8::"), options: iprange.Options{SwapReversed: true},
+		expected: []*net.IPNet{parseCIDR("2001:db8::/120")},
+	},
+	{
+		begin: net.ParseIP("10.0.0.0"), end: net.ParseIP("10.0.2.255"), options: iprange.Options{Align4: 8},
+		expected: []*net.IPNet{parseCIDR("10.0.0.0/24"), parseCIDR("10.0.1.0/24"), parseCIDR("10.0.2.0/24")},
+	},
+	{
+		begin: net.ParseIP("2001:db8::"), end: net.ParseIP("2001:db9:ffff:ffff:ffff:ffff:ffff:ffff"), options: iprange.Options{Align4: 8, Align6: 4},
+		expected: []*net.IPNet{parseCIDR("2001:db8::/32"), parseCIDR("2001:db9::/32")},
+	},
+	{
+		begin: net.ParseIP("10.0.0.0"), end: net.ParseIP("10.0.0.255"), options: iprange.Options{Align4: 5},
+		err: iprange.ErrIncorrectStep,
+	},
+}
+
+func TestMergeOptions(t *testing.T) {
+	for _, r := range testTableMergeOptions {
+		subnets, err := r.options.Merge(r.begin, r.end)
+		if !errors.Is(err, r.err) {
+			t.Errorf("%s-%s: got error %v, expected %v", r.begin, r.end, err, r.err)
+		}
+
 		if diff := deep.Equal(subnets, r.expected); diff != nil {
 			t.Errorf("got %v, expected %v: %v", subnets, r.expected, diff)
 		}
 	}
 }
 
+func TestRangeError(t *testing.T) {
+	_, err := iprange.Merge(net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.0"))
+
+	var rangeErr *iprange.RangeError
+	if !errors.As(err, &rangeErr) {
+		t.Fatalf("got %v, expected *iprange.RangeError", err)
+	}
+
+	if !rangeErr.Begin.Equal(net.ParseIP("10.0.0.3")) || !rangeErr.End.Equal(net.ParseIP("10.0.0.0")) {
+		t.Errorf("got %s-%s, expected 10.0.0.3-10.0.0.0", rangeErr.Begin, rangeErr.End)
+	}
+
+	if !errors.Is(err, iprange.ErrIncorrectRange) {
+		t.Errorf("got %v, expected to match %v", err, iprange.ErrIncorrectRange)
+	}
+}
+
 func BenchmarkMergeRange(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		for _, r := range testTableMergeRangeV4 {
-			iprange.Merge(r.begin, r.end)
+			iprange.Merge(r.begin, r.end) // nolint: errcheck
 		}
 	}
 }
@@ -0,0 +1,218 @@
+package mergeips
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+// FindingKind tells what Lint found in the input line
+type FindingKind int
+
+// Finding kinds
+const (
+	// FindingInvalid means the line could not be parsed, Err tells why
+	FindingInvalid FindingKind = iota
+	// FindingNonCanonical means the CIDR has host bits set, Expected is the canonical form
+	FindingNonCanonical
+	// FindingDuplicate means the very same subnet is defined by the Related lines as well
+	FindingDuplicate
+	// FindingRedundant means the line is fully covered by the Related lines
+	FindingRedundant
+	// FindingMergeable means the line could be merged with the Related lines to Expected subnet
+	FindingMergeable
+)
+
+var findingKindNames = []string{ // nolint: gochecknoglobals
+	FindingInvalid:      "invalid",
+	FindingNonCanonical: "non-canonical",
+	FindingDuplicate:    "duplicate",
+	FindingRedundant:    "redundant",
+	FindingMergeable:    "mergeable",
+}
+
+// String implements fmt.Stringer
+func (k FindingKind) String() string {
+	if k < 0 || int(k) >= len(findingKindNames) {
+		return "FindingKind(" + strconv.Itoa(int(k)) + ")"
+	}
+
+	return findingKindNames[k]
+}
+
+// Finding describes a problem found in the input line
+// Line and Related are 1-based line numbers
+type Finding struct {
+	Line     int
+	Input    string
+	Kind     FindingKind
+	Expected string
+	Related  []int
+	Err      error
+}
+
+// Lint parses the source and reports the problems found in every line
+// See Parser.Lint
+func Lint(s Scanner) ([]Finding, error) {
+	return Parser{}.Lint(s)
+}
+
+// Lint parses the source and reports the problems found in every line:
+// lines failed to parse, non-canonical CIDRs with the canonical form expected,
+// duplicates, lines fully covered by other lines and lines mergeable with other ones.
+// Strict option is ignored: non-canonical CIDRs are reported and used as canonical ones.
+// Findings are ordered by line, the error returned is the Scanner one.
+func (p Parser) Lint(s Scanner) ([]Finding, error) {
+	var (
+		findings []Finding
+		lines    []string
+		entries  []subnet.Sourced
+	)
+
+	p.Strict = false
+
+	for s.Scan() {
+		line := s.Text()
+		lines = append(lines, line)
+		idx := len(lines) - 1
+
+		nets, err := p.Parse(line)
+		if err != nil {
+			findings = append(findings, Finding{Line: idx + 1, Input: line, Kind: FindingInvalid, Err: err})
+			continue
+		}
+
+		if strings.Contains(line, "/") {
+			var parseErr *ParseError
+			if _, err = (Parser{Strict: true, Mapped: p.Mapped}).Parse(line); errors.As(err, &parseErr) &&
+				parseErr.Reason == ReasonNonCanonical {
+				findings = append(
+					findings,
+					Finding{Line: idx + 1, Input: line, Kind: FindingNonCanonical, Expected: ipnet.String(nets[0])},
+				)
+			}
+		}
+
+		for _, n := range nets {
+			entries = append(entries, subnet.Sourced{Subnet: subnet.FromIPNet(n), Sources: []int{idx}})
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	findings = append(findings, lintEntries(lines, entries)...)
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+
+	return findings, nil
+}
+
+// lintEntries reports duplicate, redundant and mergeable lines
+// The line is duplicate or redundant only if all the subnets it defines are removed by de-dup
+func lintEntries(lines []string, entries []subnet.Sourced) (findings []Finding) {
+	var (
+		total   = make(map[int]int)
+		removed = make(map[int]int)
+		equal   = make(map[int]int)
+		by      = make(map[int]map[int]bool)
+	)
+
+	for _, e := range entries {
+		total[e.Sources[0]]++
+	}
+
+	// the lines removed by de-dup are not the reason to merge, so only the lines kept are taken into account
+	deduped := dedupKept(
+		subnet.SortSourced(entries),
+		func(kept, dropped subnet.Sourced) {
+			line := dropped.Sources[0]
+
+			removed[line]++
+
+			if kept.Subnet == dropped.Subnet {
+				equal[line]++
+			}
+
+			if by[line] == nil {
+				by[line] = make(map[int]bool)
+			}
+
+			by[line][kept.Sources[0]] = true
+		},
+	)
+
+	redundant := make(map[int]bool)
+
+	for line, count := range removed {
+		if count != total[line] {
+			continue
+		}
+
+		kind := FindingRedundant
+		if equal[line] == count {
+			kind = FindingDuplicate
+		}
+
+		redundant[line] = true
+
+		findings = append(
+			findings,
+			Finding{Line: line + 1, Input: lines[line], Kind: kind, Related: lineNumbers(by[line])},
+		)
+	}
+
+	for _, group := range subnet.MergeSortedSourced(deduped) {
+		var members []int
+
+		for _, line := range group.Sources {
+			if !redundant[line] {
+				members = append(members, line)
+			}
+		}
+
+		if len(members) < 2 {
+			continue
+		}
+
+		for _, line := range members {
+			related := make(map[int]bool, len(members)-1)
+
+			for _, other := range members {
+				if other != line {
+					related[other] = true
+				}
+			}
+
+			findings = append(
+				findings,
+				Finding{
+					Line:     line + 1,
+					Input:    lines[line],
+					Kind:     FindingMergeable,
+					Expected: group.String(),
+					Related:  lineNumbers(related),
+				},
+			)
+		}
+	}
+
+	return findings
+}
+
+func lineNumbers(idx map[int]bool) []int {
+	res := make([]int, 0, len(idx))
+
+	for i := range idx {
+		res = append(res, i+1)
+	}
+
+	sort.Ints(res)
+
+	return res
+}
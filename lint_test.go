@@ -0,0 +1,70 @@
+package mergeips_test
+
+import (
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testLintRow struct {
+	in       []string
+	expected []mergeips.Finding
+}
+
+var testLintData = []testLintRow{
+	{
+		in:       []string{"10.0.0.0/24", "10.0.2.0/24"},
+		expected: nil,
+	},
+	{
+		in: []string{
+			"10.0.0.5/24",
+			"10.0.1.0/24",
+			"10.0.0.7",
+			"10.0.1.0/24",
+			"bad",
+			"10.0.4.0-10.0.4.255",
+			"10.0.5.0/24",
+		},
+		expected: []mergeips.Finding{
+			{Line: 1, Input: "10.0.0.5/24", Kind: mergeips.FindingNonCanonical, Expected: "10.0.0.0/24"},
+			{Line: 1, Input: "10.0.0.5/24", Kind: mergeips.FindingMergeable, Expected: "10.0.0.0/23", Related: []int{2}},
+			{Line: 2, Input: "10.0.1.0/24", Kind: mergeips.FindingMergeable, Expected: "10.0.0.0/23", Related: []int{1}},
+			{Line: 3, Input: "10.0.0.7", Kind: mergeips.FindingRedundant, Related: []int{1}},
+			{Line: 4, Input: "10.0.1.0/24", Kind: mergeips.FindingDuplicate, Related: []int{2}},
+			{Line: 5, Input: "bad", Kind: mergeips.FindingInvalid},
+			{Line: 6, Input: "10.0.4.0-10.0.4.255", Kind: mergeips.FindingMergeable, Expected: "10.0.4.0/23", Related: []int{7}},
+			{Line: 7, Input: "10.0.5.0/24", Kind: mergeips.FindingMergeable, Expected: "10.0.4.0/23", Related: []int{6}},
+		},
+	},
+	{
+		in: []string{
+			"10.0.0.128/25",
+			"10.0.0.0/24",
+			"10.0.0.0-10.0.1.127",
+		},
+		expected: []mergeips.Finding{
+			{Line: 1, Input: "10.0.0.128/25", Kind: mergeips.FindingRedundant, Related: []int{2}},
+		},
+	},
+}
+
+func TestLint(t *testing.T) {
+	for _, row := range testLintData {
+		findings, err := mergeips.Lint(&stringSliceScanner{data: row.in, next: -1})
+		if err != nil {
+			t.Error(err)
+		}
+
+		for i := range findings {
+			if findings[i].Kind == mergeips.FindingInvalid && findings[i].Err != nil {
+				findings[i].Err = nil
+			}
+		}
+
+		if diff := deep.Equal(findings, row.expected); diff != nil {
+			t.Errorf("%v: got %v, expected %v: %v", row.in, findings, row.expected, diff)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package netset
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"net"
+	"sort"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+)
+
+// ErrOutOfRange is returned by Addresses.Nth for the index out of the addresses list
+var ErrOutOfRange = errors.New("index out of range")
+
+// Addresses is the list of the individual addresses of a set, in address order.
+// Every address is computed on demand, so even ::/0 is never materialised.
+type Addresses struct {
+	ranges []addressRange
+	count  *big.Int
+}
+
+// addressRange is the addresses of one subnet of the set
+type addressRange struct {
+	first  int128.Uint128
+	last   int128.Uint128
+	bits   int
+	offset *big.Int // the number of addresses in the ranges before
+}
+
+// Addresses returns the list of the individual addresses of the set.
+// With skipNetworkBroadcast the network and broadcast addresses of IPv4 subnets /0 to /30 are skipped.
+func (s *Set) Addresses(skipNetworkBroadcast bool) *Addresses {
+	var (
+		a   = &Addresses{count: big.NewInt(0), ranges: make([]addressRange, 0, len(s.subnets))}
+		one = int128.Uint128FromUint64s(0, 1)
+	)
+
+	for _, sn := range s.subnets {
+		var (
+			mask = sn.Mask()
+			size = mask.Size.BigInt()
+			r    = addressRange{
+				first:  sn.IP,
+				last:   sn.IP.RangeEnd(mask.Mask),
+				bits:   sn.Bits,
+				offset: big.NewInt(0).Set(a.count),
+			}
+		)
+
+		if skipNetworkBroadcast && sn.Bits == 32 && sn.Ones <= 30 {
+			r.first, _ = r.first.Add(one)
+			r.last, _ = r.last.Sub(one)
+			size.Sub(size, big.NewInt(2))
+		}
+
+		a.ranges = append(a.ranges, r)
+		a.count.Add(a.count, size)
+	}
+
+	return a
+}
+
+// Sample returns n addresses picked from the set uniformly at random by rng, see Addresses.Sample
+func (s *Set) Sample(rng *rand.Rand, n int) []net.IP {
+	return s.Addresses(false).Sample(rng, n)
+}
+
+// Count returns the number of addresses in the list
+func (a *Addresses) Count() *big.Int {
+	return big.NewInt(0).Set(a.count)
+}
+
+// Nth returns the address of index i in the list, starting from 0
+func (a *Addresses) Nth(i *big.Int) (net.IP, error) {
+	if i.Sign() < 0 || i.Cmp(a.count) >= 0 {
+		return nil, fmt.Errorf("%s of %s: %w", i, a.count, ErrOutOfRange)
+	}
+
+	k := sort.Search(len(a.ranges), func(k int) bool { return a.ranges[k].offset.Cmp(i) > 0 }) - 1
+
+	offset, err := int128.Uint128FromBigInt(big.NewInt(0).Sub(i, a.ranges[k].offset))
+	if err != nil {
+		panic("unreachable reached")
+	}
+
+	ip, _ := a.ranges[k].first.Add(offset)
+
+	return ip.IP(a.ranges[k].bits), nil
+}
+
+// Iterator returns the iterator over the addresses of the list, in address order
+func (a *Addresses) Iterator() *AddressIterator {
+	return &AddressIterator{addresses: a}
+}
+
+// Shuffle returns the iterator over the addresses of the list in the random order defined by rng,
+// so the addresses of the same subnet are not visited sequentially.
+// Every address is visited exactly once, the same rng seed gives the same order.
+// The order is a pseudo-random permutation, not suitable for any cryptographic purpose.
+func (a *Addresses) Shuffle(rng *rand.Rand) *AddressIterator {
+	return &AddressIterator{addresses: a, permutation: newPermutation(a.count, rng)}
+}
+
+// AddressIterator iterates over the addresses of the list, computing every one on demand
+type AddressIterator struct {
+	addresses   *Addresses
+	permutation *permutation
+	index       int
+	current     int128.Uint128
+	ip          net.IP
+	started     bool
+	done        bool
+}
+
+// Next advances the iterator to the next address, returns false if there are no more
+func (it *AddressIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.permutation != nil {
+		return it.nextPermuted()
+	}
+
+	ranges := it.addresses.ranges
+
+	switch {
+	case !it.started:
+		it.started = true
+	case it.current.Cmp(ranges[it.index].last) < 0:
+		it.current, _ = it.current.Add(int128.Uint128FromUint64s(0, 1))
+		it.ip = it.current.IP(ranges[it.index].bits)
+
+		return true
+	default:
+		it.index++
+	}
+
+	if it.index >= len(ranges) {
+		it.done = true
+		return false
+	}
+
+	it.current = ranges[it.index].first
+	it.ip = it.current.IP(ranges[it.index].bits)
+
+	return true
+}
+
+// IP returns the current address
+func (it *AddressIterator) IP() net.IP {
+	return it.ip
+}
+
+func (it *AddressIterator) nextPermuted() bool {
+	i, ok := it.permutation.next()
+	if !ok {
+		it.done = true
+		return false
+	}
+
+	ip, err := it.addresses.Nth(i)
+	if err != nil {
+		panic("unreachable reached")
+	}
+
+	it.ip = ip
+
+	return true
+}
+
+// permutation enumerates the indexes 0 to n-1 in random order.
+// The full period linear congruential generator modulo the power of two not less than n is used,
+// its values scrambled by xorshift and odd multiplication, both bijective modulo the power of two,
+// and the results not less than n are skipped.
+type permutation struct {
+	n          *big.Int
+	modulus    *big.Int
+	multiplier *big.Int
+	increment  *big.Int
+	scrambler  *big.Int
+	shift      uint
+	current    *big.Int
+	left       *big.Int
+}
+
+func newPermutation(n *big.Int, rng *rand.Rand) *permutation {
+	p := &permutation{
+		n:          n,
+		modulus:    big.NewInt(1),
+		multiplier: big.NewInt(1),
+		increment:  big.NewInt(0),
+		scrambler:  big.NewInt(1),
+		current:    big.NewInt(0),
+		left:       big.NewInt(0).Set(n),
+	}
+
+	if n.Sign() > 0 {
+		width := uint(big.NewInt(0).Sub(n, big.NewInt(1)).BitLen())
+		p.modulus.Lsh(p.modulus, width)
+		p.shift = (width + 1) / 2
+	}
+
+	// Hull-Dobell theorem: the increment is odd and the multiplier is 1 modulo 4
+	if p.modulus.Cmp(big.NewInt(4)) >= 0 {
+		m := big.NewInt(0).Rand(rng, big.NewInt(0).Rsh(p.modulus, 2))
+		p.multiplier.Add(m.Lsh(m, 2), p.multiplier)
+	}
+
+	if p.modulus.Cmp(big.NewInt(2)) >= 0 {
+		c := big.NewInt(0).Rand(rng, big.NewInt(0).Rsh(p.modulus, 1))
+		p.increment.Add(c.Lsh(c, 1), big.NewInt(1))
+		s := big.NewInt(0).Rand(rng, big.NewInt(0).Rsh(p.modulus, 1))
+		p.scrambler.Add(s.Lsh(s, 1), big.NewInt(1))
+		p.current.Rand(rng, p.modulus)
+	}
+
+	return p
+}
+
+func (p *permutation) next() (*big.Int, bool) {
+	if p.left.Sign() == 0 {
+		return nil, false
+	}
+
+	p.left.Sub(p.left, big.NewInt(1))
+
+	for {
+		p.current.Mul(p.current, p.multiplier)
+		p.current.Add(p.current, p.increment)
+		p.current.Mod(p.current, p.modulus)
+
+		if i := p.scramble(p.current); i.Cmp(p.n) < 0 {
+			return i, true
+		}
+	}
+}
+
+func (p *permutation) scramble(x *big.Int) *big.Int {
+	if p.shift == 0 {
+		return big.NewInt(0).Set(x)
+	}
+
+	res := big.NewInt(0).Rsh(x, p.shift)
+	res.Xor(res, x)
+	res.Mul(res, p.scrambler)
+	res.Mod(res, p.modulus)
+
+	return res.Xor(res, big.NewInt(0).Rsh(res, p.shift))
+}
+
+// Sample returns n addresses picked from the list uniformly at random by rng, with repetitions,
+// so every subnet of the set gets the share of the addresses proportional to its size.
+// rand.New(rand.NewSource(seed)) as rng makes the sample reproducible.
+func (a *Addresses) Sample(rng *rand.Rand, n int) []net.IP {
+	if n <= 0 || a.count.Sign() == 0 {
+		return nil
+	}
+
+	res := make([]net.IP, 0, n)
+
+	for len(res) < n {
+		ip, err := a.Nth(big.NewInt(0).Rand(rng, a.count))
+		if err != nil {
+			panic("unreachable reached")
+		}
+
+		res = append(res, ip)
+	}
+
+	return res
+}
@@ -0,0 +1,176 @@
+package netset_test
+
+import (
+	"errors"
+	"math/big"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips/netset"
+)
+
+type testAddressesRow struct {
+	in       []string
+	skip     bool
+	count    string
+	expected []string
+}
+
+var testAddressesData = []testAddressesRow{
+	{
+		in:       []string{"10.0.0.8/31", "10.0.0.0/30", "10.0.0.4/30", "2001:db8::/127", "192.168.0.1/32"},
+		count:    "13",
+		expected: []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.7", "10.0.0.8", "10.0.0.9", "192.168.0.1", "2001:db8::", "2001:db8::1"},
+	},
+	{
+		in:       []string{"10.0.0.8/31", "10.0.0.0/30", "10.0.0.4/30", "2001:db8::/127", "192.168.0.1/32"},
+		skip:     true,
+		count:    "11",
+		expected: []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6", "10.0.0.8", "10.0.0.9", "192.168.0.1", "2001:db8::", "2001:db8::1"},
+	},
+	{
+		in:       []string{"255.255.255.252/30", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/127"},
+		skip:     true,
+		count:    "4",
+		expected: []string{"255.255.255.253", "255.255.255.254", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	},
+	{
+		in:    nil,
+		count: "0",
+	},
+}
+
+func TestAddresses(t *testing.T) {
+	for _, row := range testAddressesData {
+		addresses := netset.New(parseCIDRs(row.in)).Addresses(row.skip)
+
+		if count := addresses.Count().String(); count != row.count {
+			t.Errorf("%v: got count %s, expected %s", row.in, count, row.count)
+		}
+
+		var got []string
+
+		for it := addresses.Iterator(); it.Next(); {
+			got = append(got, it.IP().String())
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%v: %v", row.in, diff)
+		}
+
+		for i, expected := range row.expected {
+			ip, err := addresses.Nth(big.NewInt(int64(i)))
+			if err != nil || ip.String() != expected {
+				t.Errorf("%v: got %d address %v, %v, expected %s", row.in, i, ip, err, expected)
+			}
+		}
+
+		if _, err := addresses.Nth(big.NewInt(int64(len(row.expected)))); !errors.Is(err, netset.ErrOutOfRange) {
+			t.Errorf("%v: got error %v, expected %v", row.in, err, netset.ErrOutOfRange)
+		}
+
+		shuffled := make([]string, 0, len(row.expected))
+
+		for it := addresses.Shuffle(rand.New(rand.NewSource(1))); it.Next(); { // nolint: gosec
+			shuffled = append(shuffled, it.IP().String())
+		}
+
+		// the order of the strings sorted is not the order of the addresses, so both lists are sorted
+		sort.Strings(shuffled)
+		sort.Strings(got)
+
+		if diff := deep.Equal(shuffled, got); len(got) > 0 && diff != nil {
+			t.Errorf("%v: shuffled %v", row.in, diff)
+		}
+	}
+}
+
+func TestAddressesHuge(t *testing.T) {
+	addresses := netset.New(parseCIDRs([]string{"0.0.0.0/0", "::/0"})).Addresses(true)
+
+	// 2^128 + 2^32 - 2
+	if count := addresses.Count().String(); count != "340282366920938463463374607436063178750" {
+		t.Errorf("got count %s", count)
+	}
+
+	for _, row := range []struct {
+		i        string
+		expected string
+	}{
+		{i: "0", expected: "0.0.0.1"},
+		{i: "4294967293", expected: "255.255.255.254"},
+		{i: "4294967294", expected: "::"},
+		{i: "340282366920938463463374607436063178749", expected: "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"},
+	} {
+		i, _ := big.NewInt(0).SetString(row.i, 10)
+
+		ip, err := addresses.Nth(i)
+		if err != nil || ip.String() != row.expected {
+			t.Errorf("%s: got %v, %v, expected %s", row.i, ip, err, row.expected)
+		}
+	}
+
+	if _, err := addresses.Nth(big.NewInt(-1)); !errors.Is(err, netset.ErrOutOfRange) {
+		t.Errorf("got error %v, expected %v", err, netset.ErrOutOfRange)
+	}
+
+	var first, second []string
+
+	for _, list := range []*[]string{&first, &second} {
+		it := addresses.Shuffle(rand.New(rand.NewSource(42))) // nolint: gosec
+		for len(*list) < 10 && it.Next() {
+			*list = append(*list, it.IP().String())
+		}
+	}
+
+	if diff := deep.Equal(first, second); diff != nil || len(first) != 10 {
+		t.Errorf("%v: %v", first, diff)
+	}
+}
+
+func TestSample(t *testing.T) {
+	var (
+		in  = []string{"10.0.0.0/24", "10.1.0.0/22", "2001:db8::/120"}
+		set = netset.New(parseCIDRs(in))
+		got = set.Sample(rand.New(rand.NewSource(1)), 1536) // nolint: gosec
+	)
+
+	if diff := deep.Equal(got, set.Sample(rand.New(rand.NewSource(1)), 1536)); diff != nil { // nolint: gosec
+		t.Errorf("same seed, different samples: %v", diff)
+	}
+
+	counts := make(map[string]int)
+
+	for _, ip := range got {
+		for _, s := range in {
+			if parseCIDR(s).Contains(ip) {
+				counts[s]++
+			}
+		}
+	}
+
+	// 256, 1024 and 256 addresses expected on average
+	for s, expected := range map[string]int{"10.0.0.0/24": 256, "10.1.0.0/22": 1024, "2001:db8::/120": 256} {
+		if counts[s] < expected*3/4 || counts[s] > expected*5/4 {
+			t.Errorf("%s: got %d addresses, expected about %d", s, counts[s], expected)
+		}
+	}
+
+	if total := counts["10.0.0.0/24"] + counts["10.1.0.0/22"] + counts["2001:db8::/120"]; total != len(got) {
+		t.Errorf("got %d addresses out of the set", len(got)-total)
+	}
+
+	// 2^24 IPv4 addresses against 2^96 IPv6 ones
+	for _, ip := range netset.New(parseCIDRs([]string{"10.0.0.0/8", "2001:db8::/32"})).Sample(rand.New(rand.NewSource(1)), 100) { // nolint: gosec
+		if ip.To4() != nil {
+			t.Errorf("got IPv4 address %s", ip)
+		}
+	}
+
+	if got = netset.New(nil).Sample(rand.New(rand.NewSource(1)), 10); got != nil { // nolint: gosec
+		t.Errorf("got %v from the empty set", got)
+	}
+}
@@ -0,0 +1,168 @@
+package netset
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Errors returned by Allocator
+var (
+	ErrNoSpace      = errors.New("no free space")
+	ErrNotAllocated = errors.New("not allocated")
+)
+
+// Strategy defines which free prefix Allocator returns
+type Strategy int
+
+// Strategies available
+const (
+	// StrategyLowest allocates the lowest free prefix
+	StrategyLowest Strategy = iota
+	// StrategyBestFit allocates the lowest prefix of the smallest free block it fits,
+	// keeping the bigger free blocks for the bigger requests
+	StrategyBestFit
+)
+
+// Allocator allocates the prefixes of the parent block, keeping the set of prefixes allocated
+type Allocator struct {
+	// Strategy defines which free prefix Allocate returns
+	Strategy Strategy
+
+	parent    subnet.Subnet
+	allocated []subnet.Subnet
+}
+
+// NewAllocator returns the Allocator of the parent block with the prefixes provided already allocated.
+// The prefixes, or their parts, out of the parent block are ignored.
+func NewAllocator(parent *net.IPNet, allocated []*net.IPNet) *Allocator {
+	a := &Allocator{parent: subnet.FromIPNet(parent)}
+
+	for _, n := range allocated {
+		s := subnet.FromIPNet(n)
+
+		switch {
+		case a.parent.Include(s):
+			a.allocated = append(a.allocated, s)
+		case s.Include(a.parent):
+			a.allocated = append(a.allocated, a.parent)
+		}
+	}
+
+	a.allocated = subnet.Merge(a.allocated)
+
+	return a
+}
+
+// Allocated returns the prefixes allocated, merged
+func (a *Allocator) Allocated() []*net.IPNet {
+	return (&Set{subnets: a.allocated}).IPNets()
+}
+
+// Free returns the free space of the parent block as the list of prefixes, as compact as possible
+func (a *Allocator) Free() []*net.IPNet {
+	return (&Set{subnets: a.free()}).IPNets()
+}
+
+// Allocate allocates and returns the free prefix of ones length, see Strategy
+func (a *Allocator) Allocate(ones int) (*net.IPNet, error) {
+	if ones < a.parent.Ones || ones > a.parent.Bits {
+		return nil, fmt.Errorf("/%d in %s: %w", ones, a.parent, ErrIncorrectLength)
+	}
+
+	// every aligned free prefix is inside one of the free blocks, as they are as big as possible
+	found := -1
+	free := a.free()
+
+	for i, block := range free {
+		if block.Ones > ones {
+			continue
+		}
+
+		if found < 0 || a.Strategy == StrategyBestFit && block.Ones > free[found].Ones {
+			found = i
+		}
+
+		if a.Strategy == StrategyLowest {
+			break
+		}
+	}
+
+	if found < 0 {
+		return nil, fmt.Errorf("/%d in %s: %w", ones, a.parent, ErrNoSpace)
+	}
+
+	res := subnet.Subnet{IP: free[found].IP, Ones: ones, Bits: a.parent.Bits}
+	a.allocated = subnet.Merge(append(a.allocated, res))
+
+	return res.IPNet(), nil
+}
+
+// Release makes the prefix, allocated before, free
+// The prefix might be a part of the allocated one, but not a part of two or more ones.
+func (a *Allocator) Release(n *net.IPNet) error {
+	s := subnet.FromIPNet(n)
+
+	for i, block := range a.allocated {
+		if !block.Include(s) {
+			continue
+		}
+
+		rest := excludeSubnet(block, s)
+		a.allocated = subnet.Merge(append(append(rest, a.allocated[:i]...), a.allocated[i+1:]...))
+
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", s, ErrNotAllocated)
+}
+
+// free returns the complement of the allocated prefixes within the parent block
+func (a *Allocator) free() (res []subnet.Subnet) {
+	var (
+		mask    = a.parent.Mask().Mask
+		current = a.parent.IP
+		end     = a.parent.IP.RangeEnd(mask)
+		one     = int128.Uint128FromUint64s(0, 1)
+	)
+
+	for _, s := range a.allocated {
+		if s.IP.Cmp(current) > 0 {
+			last, _ := s.IP.Sub(one)
+			res = append(res, subnet.FromRange(current, last, a.parent.Bits)...)
+		}
+
+		sEnd := s.IP.RangeEnd(s.Mask().Mask)
+		if sEnd.Cmp(end) == 0 {
+			return res
+		}
+
+		current, _ = sEnd.Add(one)
+	}
+
+	return append(res, subnet.FromRange(current, end, a.parent.Bits)...)
+}
+
+// excludeSubnet returns the parts of block left after s, included into block, excluded
+func excludeSubnet(block, s subnet.Subnet) (res []subnet.Subnet) {
+	var (
+		one      = int128.Uint128FromUint64s(0, 1)
+		blockEnd = block.IP.RangeEnd(block.Mask().Mask)
+		sEnd     = s.IP.RangeEnd(s.Mask().Mask)
+	)
+
+	if s.IP.Cmp(block.IP) > 0 {
+		last, _ := s.IP.Sub(one)
+		res = append(res, subnet.FromRange(block.IP, last, block.Bits)...)
+	}
+
+	if sEnd.Cmp(blockEnd) < 0 {
+		first, _ := sEnd.Add(one)
+		res = append(res, subnet.FromRange(first, blockEnd, block.Bits)...)
+	}
+
+	return res
+}
@@ -0,0 +1,167 @@
+package netset_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips/netset"
+)
+
+type testAllocateRow struct {
+	parent    string
+	allocated []string
+	strategy  netset.Strategy
+	ones      []int
+	expected  []string
+	err       error
+}
+
+var testAllocateData = []testAllocateRow{
+	{
+		parent:    "10.20.0.0/16",
+		allocated: []string{"10.20.0.0/26", "10.20.0.128/25", "10.21.0.0/16", "192.168.0.0/24", "2001:db8::/32"},
+		ones:      []int{26, 26, 24},
+		expected:  []string{"10.20.0.64/26", "10.20.1.0/26", "10.20.2.0/24"},
+	},
+	{
+		parent:    "10.20.0.0/16",
+		allocated: []string{"10.20.0.0/26", "10.20.0.128/25", "10.20.1.128/27"},
+		ones:      []int{26, 26},
+		expected:  []string{"10.20.0.64/26", "10.20.1.0/26"},
+	},
+	{
+		// best fit takes /27 hole in 10.20.1.0/25 instead of the lower /26 one
+		parent:    "10.20.0.0/16",
+		allocated: []string{"10.20.0.0/26", "10.20.0.128/25", "10.20.1.0/27", "10.20.1.64/26", "10.20.1.128/25"},
+		strategy:  netset.StrategyBestFit,
+		ones:      []int{27, 27},
+		expected:  []string{"10.20.1.32/27", "10.20.0.64/27"},
+	},
+	{
+		parent:   "10.20.0.0/30",
+		ones:     []int{31, 31, 32},
+		expected: []string{"10.20.0.0/31", "10.20.0.2/31"},
+		err:      netset.ErrNoSpace,
+	},
+	{
+		parent:    "10.20.0.0/24",
+		allocated: []string{"10.0.0.0/8"},
+		ones:      []int{30},
+		err:       netset.ErrNoSpace,
+	},
+	{
+		parent:   "10.20.0.0/24",
+		ones:     []int{23},
+		err:      netset.ErrIncorrectLength,
+		expected: nil,
+	},
+	{
+		parent:    "2001:db8::/32",
+		allocated: []string{"2001:db8::/48", "2001:db8:2::/47"},
+		ones:      []int{48, 48, 64},
+		expected:  []string{"2001:db8:1::/48", "2001:db8:4::/48", "2001:db8:5::/64"},
+	},
+	{
+		parent:    "::/0",
+		allocated: []string{"::/1"},
+		ones:      []int{1},
+		expected:  []string{"8000::/1"},
+	},
+}
+
+func TestAllocate(t *testing.T) {
+	for _, row := range testAllocateData {
+		a := netset.NewAllocator(parseCIDR(row.parent), parseCIDRs(row.allocated))
+		a.Strategy = row.strategy
+
+		var (
+			got []string
+			err error
+		)
+
+		for _, ones := range row.ones {
+			var n *net.IPNet
+			if n, err = a.Allocate(ones); err != nil {
+				break
+			}
+
+			got = append(got, n.String())
+		}
+
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.parent, err, row.err)
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%s: %v", row.parent, diff)
+		}
+	}
+}
+
+func TestAllocatorRelease(t *testing.T) {
+	a := netset.NewAllocator(parseCIDR("10.20.0.0/24"), parseCIDRs([]string{"10.20.0.0/25", "10.20.0.128/26"}))
+
+	if diff := deep.Equal(netStrings(a.Free()), []string{"10.20.0.192/26"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := a.Release(parseCIDR("10.20.0.32/27")); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(netStrings(a.Allocated()), []string{"10.20.0.0/27", "10.20.0.64/26", "10.20.0.128/26"}); diff != nil {
+		t.Error(diff)
+	}
+
+	if diff := deep.Equal(netStrings(a.Free()), []string{"10.20.0.32/27", "10.20.0.192/26"}); diff != nil {
+		t.Error(diff)
+	}
+
+	n, err := a.Allocate(27)
+	if err != nil || n.String() != "10.20.0.32/27" {
+		t.Errorf("got %v, %v, expected 10.20.0.32/27", n, err)
+	}
+
+	for _, s := range []string{"10.20.0.192/26", "10.20.0.0/23", "2001:db8::/64"} {
+		if err = a.Release(parseCIDR(s)); !errors.Is(err, netset.ErrNotAllocated) {
+			t.Errorf("%s: got error %v, expected %v", s, err, netset.ErrNotAllocated)
+		}
+	}
+
+	for _, s := range []string{"10.20.0.0/25", "10.20.0.128/26"} {
+		if err = a.Release(parseCIDR(s)); err != nil {
+			t.Errorf("%s: %v", s, err)
+		}
+	}
+
+	if diff := deep.Equal(netStrings(a.Free()), []string{"10.20.0.0/24"}); diff != nil || a.Allocated() != nil {
+		t.Errorf("%v, allocated %v", diff, a.Allocated())
+	}
+}
+
+func parseCIDRs(list []string) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(list))
+
+	for _, s := range list {
+		res = append(res, parseCIDR(s))
+	}
+
+	return res
+}
+
+func netStrings(nets []*net.IPNet) []string {
+	if len(nets) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(nets))
+
+	for _, n := range nets {
+		res = append(res, n.String())
+	}
+
+	return res
+}
@@ -0,0 +1,286 @@
+package netset
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Errors returned by UnmarshalBinary
+var (
+	ErrInvalidData        = errors.New("invalid binary data")
+	ErrUnsupportedVersion = errors.New("unsupported binary format version")
+	ErrChecksum           = errors.New("binary data checksum mismatch")
+)
+
+// Binary format:
+//
+//	magic "MIPS", version byte
+//	family sections, IPv4 first, families with no subnets omitted:
+//		family byte, 4 or 6
+//		subnets count, uvarint
+//		subnet addresses, every one as uvarint of the gap after the previous subnet end
+//		prefix lengths, bit-packed, 6 bits for IPv4, 8 bits for IPv6, padded to a byte
+//	CRC-32 (IEEE) of all the above, big-endian
+const (
+	binaryMagic   = "MIPS"
+	binaryVersion = 1
+	checksumLen   = 4
+	// maxVarintLen128 is the maximum length of 128-bit uvarint
+	maxVarintLen128 = 19
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler
+func (s *Set) MarshalBinary() ([]byte, error) {
+	res := append([]byte(binaryMagic), binaryVersion)
+
+	for start := 0; start < len(s.subnets); {
+		end := start
+		for end < len(s.subnets) && s.subnets[end].Bits == s.subnets[start].Bits {
+			end++
+		}
+
+		res = appendSection(res, s.subnets[start:end])
+		start = end
+	}
+
+	return appendChecksum(res), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// The data is verified: checksum, version, subnets are sorted, not overlapping and have no host bits set
+func (s *Set) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+1+checksumLen || string(data[:len(binaryMagic)]) != binaryMagic {
+		return fmt.Errorf("no header: %w", ErrInvalidData)
+	}
+
+	if version := data[len(binaryMagic)]; version != binaryVersion {
+		return fmt.Errorf("version %d: %w", version, ErrUnsupportedVersion)
+	}
+
+	body, sum := data[:len(data)-checksumLen], data[len(data)-checksumLen:]
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(sum) {
+		return ErrChecksum
+	}
+
+	var (
+		subnets []subnet.Subnet
+		rest    = body[len(binaryMagic)+1:]
+		prev    int
+	)
+
+	for len(rest) > 0 {
+		bits, err := familyBits(rest[0], prev)
+		if err != nil {
+			return err
+		}
+
+		prev = bits
+
+		var section []subnet.Subnet
+		if section, rest, err = readSection(rest[1:], bits); err != nil {
+			return err
+		}
+
+		subnets = append(subnets, section...)
+	}
+
+	// the data made by MarshalBinary is merged already, the rest is merged to keep Set consistent
+	s.subnets = subnet.MergeSorted(subnets)
+
+	return nil
+}
+
+func familyBits(family byte, prev int) (int, error) {
+	switch {
+	case family == 4 && prev == 0:
+		return 32, nil
+	case family == 6 && prev != 128:
+		return 128, nil
+	}
+
+	return 0, fmt.Errorf("family %d: %w", family, ErrInvalidData)
+}
+
+func appendSection(res []byte, subnets []subnet.Subnet) []byte {
+	bits := subnets[0].Bits
+
+	if bits == 32 {
+		res = append(res, 4)
+	} else {
+		res = append(res, 6)
+	}
+
+	res = appendUvarint(res, uint64(len(subnets)))
+
+	var next int128.Uint128
+
+	for _, sn := range subnets {
+		gap, _ := sn.IP.Sub(next)
+		res = appendUvarint128(res, gap)
+		next = sn.IP.Jump(sn.Mask().Mask)
+	}
+
+	var (
+		width = lengthWidth(bits)
+		acc   uint64
+		n     uint
+	)
+
+	for _, sn := range subnets {
+		acc = acc<<width | uint64(sn.Ones)
+		n += width
+
+		for n >= 8 {
+			n -= 8
+			res = append(res, byte(acc>>n))
+		}
+	}
+
+	if n > 0 {
+		res = append(res, byte(acc<<(8-n)))
+	}
+
+	return res
+}
+
+func readSection(data []byte, bits int) ([]subnet.Subnet, []byte, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count == 0 || count > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("subnets count: %w", ErrInvalidData)
+	}
+
+	data = data[n:]
+	gaps := make([]int128.Uint128, count)
+
+	for i := range gaps {
+		if gaps[i], n = uvarint128(data); n <= 0 {
+			return nil, nil, fmt.Errorf("subnet %d address: %w", i, ErrInvalidData)
+		}
+
+		data = data[n:]
+	}
+
+	width := lengthWidth(bits)
+
+	packedLen := (count*uint64(width) + 7) / 8
+	if packedLen > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("prefix lengths: %w", ErrInvalidData)
+	}
+
+	var (
+		res      = make([]subnet.Subnet, 0, count)
+		packed   = data[:packedLen]
+		next     int128.Uint128
+		overflow bool
+		maxIP    = masks.Get(0, bits).Mask.Not()
+		acc      uint64
+		accBits  uint
+	)
+
+	for i, gap := range gaps {
+		for accBits < width {
+			acc = acc<<8 | uint64(packed[0])
+			packed = packed[1:]
+			accBits += 8
+		}
+
+		accBits -= width
+		ones := int(acc >> accBits & (1<<width - 1))
+
+		var ip int128.Uint128
+		if ip, overflow = next.Add(gap); overflow || ones > bits || ip.Cmp(maxIP) > 0 {
+			return nil, nil, fmt.Errorf("subnet %d: %w", i, ErrInvalidData)
+		}
+
+		sn := subnet.Subnet{IP: ip, Ones: ones, Bits: bits}
+		mask := sn.Mask().Mask
+
+		if ip.And(mask).Cmp(ip) != 0 {
+			return nil, nil, fmt.Errorf("subnet %d: host bits set: %w", i, ErrInvalidData)
+		}
+
+		res = append(res, sn)
+
+		// the subnet ending at the very end of the address space must be the last one
+		end := ip.RangeEnd(mask)
+		if next, overflow = end.Add(int128.Uint128FromUint64s(0, 1)); overflow && i < len(gaps)-1 {
+			return nil, nil, fmt.Errorf("subnet %d: %w", i+1, ErrInvalidData)
+		}
+	}
+
+	return res, data[packedLen:], nil
+}
+
+func lengthWidth(bits int) uint {
+	if bits == 32 {
+		return 6
+	}
+
+	return 8
+}
+
+func appendChecksum(data []byte) []byte {
+	sum := make([]byte, checksumLen)
+	binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(data))
+
+	return append(data, sum...)
+}
+
+func appendUvarint(data []byte, x uint64) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	return append(data, buf[:binary.PutUvarint(buf, x)]...)
+}
+
+// appendUvarint128 appends x the same way binary.PutUvarint does, 7 bits per byte, lowest first
+func appendUvarint128(data []byte, x int128.Uint128) []byte {
+	high, low := x.Uint64s()
+
+	for high > 0 || low >= 0x80 {
+		data = append(data, byte(low)|0x80)
+		low = low>>7 | high<<57
+		high >>= 7
+	}
+
+	return append(data, byte(low))
+}
+
+// uvarint128 decodes the value written by appendUvarint128,
+// returns the number of bytes read, 0 if data is too short and negative value on overflow
+func uvarint128(data []byte) (int128.Uint128, int) {
+	var high, low uint64
+
+	for i, b := range data {
+		if i >= maxVarintLen128 {
+			return int128.Uint128{}, -i
+		}
+
+		shift := uint(7 * i)
+		value := uint64(b & 0x7f)
+
+		switch {
+		case shift < 64:
+			low |= value << shift
+			if shift > 57 {
+				high |= value >> (64 - shift)
+			}
+		default:
+			if shift-64 > 57 && value>>(128-shift) != 0 {
+				return int128.Uint128{}, -(i + 1)
+			}
+
+			high |= value << (shift - 64)
+		}
+
+		if b < 0x80 {
+			return int128.Uint128FromUint64s(high, low), i + 1
+		}
+	}
+
+	return int128.Uint128{}, 0
+}
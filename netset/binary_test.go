@@ -0,0 +1,191 @@
+package netset_test
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips/netset"
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	set := netset.New([]*net.IPNet{parseCIDR("10.0.0.0/8"), parseCIDR("10.1.0.0/16"), parseCIDR("192.168.0.1/32")})
+
+	data, err := set.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// magic, version, IPv4 section: 2 subnets,
+	// 10.0.0.0 gap from 0.0.0.0, 192.168.0.1 gap from 11.0.0.0, lengths 8 and 32 packed in 6 bits each
+	expected := "4d49505301" + "04" + "02" + "80808050" + "8180a0ad0b" + "2200"
+	if got := hex.EncodeToString(data[:len(data)-4]); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1)) // nolint: gosec
+
+	for i := 0; i < 200; i++ {
+		set := netset.New(randomNets(rnd, rnd.Intn(100)))
+
+		data, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got netset.Set
+		if err = got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %v", set, err)
+		}
+
+		if diff := deep.Equal(got.IPNets(), set.IPNets()); diff != nil {
+			t.Fatalf("%s: %v", set, diff)
+		}
+	}
+}
+
+func TestBinaryEdges(t *testing.T) {
+	for _, nets := range [][]*net.IPNet{
+		{parseCIDR("0.0.0.0/0"), parseCIDR("::/0")},
+		{parseCIDR("255.255.255.255/32"), parseCIDR("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128")},
+		{parseCIDR("0.0.0.0/32"), parseCIDR("::/128"), parseCIDR("8000::/1")},
+	} {
+		set := netset.New(nets)
+
+		data, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got netset.Set
+		if err = got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %v", set, err)
+		}
+
+		if diff := deep.Equal(got.IPNets(), set.IPNets()); diff != nil {
+			t.Errorf("%s: %v", set, diff)
+		}
+	}
+}
+
+func TestBinaryUnmerged(t *testing.T) {
+	// 10.0.0.0/25 and 10.0.0.128/25
+	data, err := hex.DecodeString("4d49505301" + "04" + "02" + "80808050" + "00" + "6590")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var set netset.Set
+	if err = set.UnmarshalBinary(withChecksum(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(set.IPNets(), []*net.IPNet{parseCIDR("10.0.0.0/24")}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+type testBinaryErrorRow struct {
+	data string
+	err  error
+}
+
+var testBinaryErrorData = []testBinaryErrorRow{
+	{data: "", err: netset.ErrInvalidData},
+	{data: "4d495053", err: netset.ErrInvalidData},
+	{data: "4e49505301", err: netset.ErrInvalidData},
+	{data: "4d49505302", err: netset.ErrUnsupportedVersion},
+	// IPv6 section before IPv4 one
+	{data: "4d49505301" + "06" + "01" + "00" + "00" + "04" + "01" + "00" + "00", err: netset.ErrInvalidData},
+	// unknown family
+	{data: "4d49505301" + "05" + "01" + "00" + "00", err: netset.ErrInvalidData},
+	// zero subnets
+	{data: "4d49505301" + "04" + "00", err: netset.ErrInvalidData},
+	// prefix lengths missing
+	{data: "4d49505301" + "04" + "02" + "00" + "00", err: netset.ErrInvalidData},
+	// prefix length 33
+	{data: "4d49505301" + "04" + "01" + "00" + "84", err: netset.ErrInvalidData},
+	// host bits set: 0.0.0.1/31
+	{data: "4d49505301" + "04" + "01" + "01" + "7c", err: netset.ErrInvalidData},
+	// IPv4 address exceeds 32 bits
+	{data: "4d49505301" + "04" + "01" + "8080808010" + "80", err: netset.ErrInvalidData},
+	// anything after 0.0.0.0/0
+	{data: "4d49505301" + "04" + "02" + "00" + "00" + "0080", err: netset.ErrInvalidData},
+	// 128-bit varint overflow
+	{data: "4d49505301" + "06" + "01" + "ffffffffffffffffffffffffffffffffffff7f" + "80", err: netset.ErrInvalidData},
+}
+
+func TestBinaryErrors(t *testing.T) {
+	for _, row := range testBinaryErrorData {
+		data, err := hex.DecodeString(row.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(data) > 4 {
+			data = withChecksum(data)
+		}
+
+		var set netset.Set
+		if err = set.UnmarshalBinary(data); !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.data, err, row.err)
+		}
+	}
+
+	data, err := netset.New([]*net.IPNet{parseCIDR("10.0.0.0/8")}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data[6]++
+
+	var set netset.Set
+	if err = set.UnmarshalBinary(data); !errors.Is(err, netset.ErrChecksum) {
+		t.Errorf("got error %v, expected %v", err, netset.ErrChecksum)
+	}
+}
+
+func withChecksum(data []byte) []byte {
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc32.ChecksumIEEE(data))
+
+	return append(data, sum...)
+}
+
+func randomNets(rnd *rand.Rand, n int) []*net.IPNet {
+	res := make([]*net.IPNet, 0, n)
+
+	for i := 0; i < n; i++ {
+		bits := 32
+		if rnd.Intn(2) == 0 {
+			bits = 128
+		}
+
+		ip := make(net.IP, bits/8)
+		rnd.Read(ip) // nolint: gosec
+
+		// keep the subnets close to each other to make them merged sometimes
+		ip[0] = byte(rnd.Intn(2))
+
+		res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-rnd.Intn(bits/4), bits)})
+	}
+
+	return res
+}
+
+func parseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
@@ -0,0 +1,57 @@
+//go:build go1.18
+// +build go1.18
+
+package netset_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips/netset"
+)
+
+// FuzzUnmarshalBinary makes sure any data either fails to decode
+// or decodes to the set which encoding decodes back to the same set and encodes to the very same data again.
+// The input is not compared to the encoding, as it might be non-canonical, like mergeable subnets or not minimal varints.
+// The checksum is appended to the data fuzzed, otherwise almost any input is rejected by checksum verification.
+func FuzzUnmarshalBinary(f *testing.F) {
+	for _, nets := range [][]*net.IPNet{
+		nil,
+		{parseCIDR("10.0.0.0/8"), parseCIDR("192.168.0.1/32")},
+		{parseCIDR("0.0.0.0/0"), parseCIDR("::/0")},
+		{parseCIDR("2001:db8::/32"), parseCIDR("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128")},
+	} {
+		data, err := netset.New(nets).MarshalBinary()
+		if err != nil {
+			f.Fatal(err)
+		}
+
+		f.Add(data[:len(data)-4])
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var set netset.Set
+		if err := set.UnmarshalBinary(withChecksum(body)); err != nil {
+			return
+		}
+
+		encoded, err := set.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var decoded netset.Set
+		if err = decoded.UnmarshalBinary(encoded); err != nil {
+			t.Fatalf("%s: %v", &set, err)
+		}
+
+		if decoded.String() != set.String() {
+			t.Fatalf("%s != %s", &decoded, &set)
+		}
+
+		if again, _ := decoded.MarshalBinary(); !bytes.Equal(again, encoded) {
+			t.Fatalf("%s: %x != %x", &set, again, encoded)
+		}
+	})
+}
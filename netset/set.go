@@ -0,0 +1,48 @@
+// Package netset provides Set, the merged list of subnets
+package netset
+
+import (
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Set is the merged list of subnets: sorted, IPv4 first, with no overlapping or mergeable subnets
+// Zero value is an empty set ready to use
+type Set struct {
+	subnets []subnet.Subnet
+}
+
+// New returns the Set of the subnets provided, merged the same way mergeips.Merge does
+func New(nets []*net.IPNet) *Set {
+	return &Set{subnets: subnet.Merge(subnet.FromIPNets(nets))}
+}
+
+// Len returns the number of subnets in the set
+func (s *Set) Len() int {
+	return len(s.subnets)
+}
+
+// IPNets returns the subnets of the set, nil for the empty one
+func (s *Set) IPNets() []*net.IPNet {
+	if len(s.subnets) == 0 {
+		return nil
+	}
+
+	return subnet.IPNets(s.subnets)
+}
+
+// String returns the subnets of the set, space separated
+func (s *Set) String() string {
+	res := make([]byte, 0, len(s.subnets)*20)
+
+	for i, sn := range s.subnets {
+		if i > 0 {
+			res = append(res, ' ')
+		}
+
+		res = append(res, sn.String()...)
+	}
+
+	return string(res)
+}
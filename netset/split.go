@@ -0,0 +1,57 @@
+package netset
+
+import (
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// ErrIncorrectLength is returned by Split, SplitN and Supernet for the prefix length not fitting the operation
+var ErrIncorrectLength = subnet.ErrIncorrectLength
+
+// Iterator iterates over the child subnets, computing every one on demand
+type Iterator struct {
+	it *subnet.Iterator
+}
+
+// Next advances the iterator to the next subnet, returns false if there are no more
+func (it *Iterator) Next() bool {
+	return it.it.Next()
+}
+
+// IPNet returns the current subnet
+func (it *Iterator) IPNet() *net.IPNet {
+	return it.it.Subnet().IPNet()
+}
+
+// Split returns the iterator over the subnets of ones length n consists of, in address order,
+// like 10.0.0.0/16 into /24s
+func Split(n *net.IPNet, ones int) (*Iterator, error) {
+	it, err := subnet.FromIPNet(n).Split(ones)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator{it: it}, nil
+}
+
+// SplitN returns the iterator over the first parts equal subnets of n,
+// the biggest ones n could be split to parts of, like the first three /26s of /24 for 3 parts
+func SplitN(n *net.IPNet, parts uint64) (*Iterator, error) {
+	it, err := subnet.FromIPNet(n).SplitN(parts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Iterator{it: it}, nil
+}
+
+// Supernet returns the subnet of ones length including n, like 10.0.0.0/8 for 10.1.2.0/24 and 8
+func Supernet(n *net.IPNet, ones int) (*net.IPNet, error) {
+	s, err := subnet.FromIPNet(n).Supernet(ones)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.IPNet(), nil
+}
@@ -0,0 +1,120 @@
+package netset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips/netset"
+)
+
+type testSplitRow struct {
+	in       string
+	ones     int
+	parts    uint64
+	max      int
+	expected []string
+	err      error
+}
+
+var testSplitData = []testSplitRow{
+	{in: "10.0.0.0/24", ones: 26, expected: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26"}},
+	{in: "10.0.0.0/24", ones: 24, expected: []string{"10.0.0.0/24"}},
+	{in: "255.255.255.0/24", ones: 25, expected: []string{"255.255.255.0/25", "255.255.255.128/25"}},
+	{in: "0.0.0.0/0", ones: 1, expected: []string{"0.0.0.0/1", "128.0.0.0/1"}},
+	{in: "::/0", ones: 1, expected: []string{"::/1", "8000::/1"}},
+	{
+		in:       "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/126",
+		ones:     128,
+		expected: []string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/128", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffd/128", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/128", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128"},
+	},
+	// 2^64 children, only the first ones are taken
+	{in: "2001:db8::/64", ones: 128, max: 2, expected: []string{"2001:db8::/128", "2001:db8::1/128"}},
+	{in: "10.0.0.0/24", ones: 23, err: netset.ErrIncorrectLength},
+	{in: "10.0.0.0/24", ones: 33, err: netset.ErrIncorrectLength},
+	{in: "10.0.0.0/24", parts: 3, expected: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26"}},
+	{in: "10.0.0.0/24", parts: 4, expected: []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26"}},
+	{in: "10.0.0.0/24", parts: 1, expected: []string{"10.0.0.0/24"}},
+	{in: "10.0.0.0/30", parts: 5, err: netset.ErrIncorrectLength},
+	{in: "10.0.0.0/30", parts: 0, err: netset.ErrIncorrectLength},
+	{in: "::/0", parts: 1 << 63, max: 1, expected: []string{"::/63"}},
+}
+
+func TestSplit(t *testing.T) {
+	for _, row := range testSplitData {
+		var (
+			it  *netset.Iterator
+			err error
+		)
+
+		if row.parts > 0 || row.ones == 0 {
+			it, err = netset.SplitN(parseCIDR(row.in), row.parts)
+		} else {
+			it, err = netset.Split(parseCIDR(row.in), row.ones)
+		}
+
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		var got []string
+
+		for it.Next() {
+			got = append(got, it.IPNet().String())
+
+			if len(got) == row.max {
+				break
+			}
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%s: %v", row.in, diff)
+		}
+	}
+}
+
+func TestSplitCount(t *testing.T) {
+	it, err := netset.Split(parseCIDR("10.0.0.0/16"), 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+
+	if count != 256 || it.Next() {
+		t.Errorf("got %d subnets, expected 256", count)
+	}
+}
+
+func TestSupernet(t *testing.T) {
+	for _, row := range []struct {
+		in       string
+		ones     int
+		expected string
+		err      error
+	}{
+		{in: "10.1.2.0/24", ones: 8, expected: "10.0.0.0/8"},
+		{in: "10.1.2.0/24", ones: 24, expected: "10.1.2.0/24"},
+		{in: "10.1.2.3/32", ones: 0, expected: "0.0.0.0/0"},
+		{in: "2001:db8:1234::/48", ones: 32, expected: "2001:db8::/32"},
+		{in: "10.1.2.0/24", ones: 25, err: netset.ErrIncorrectLength},
+		{in: "10.1.2.0/24", ones: -1, err: netset.ErrIncorrectLength},
+	} {
+		n, err := netset.Supernet(parseCIDR(row.in), row.ones)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		if err == nil && n.String() != row.expected {
+			t.Errorf("%s: got %s, expected %s", row.in, n, row.expected)
+		}
+	}
+}
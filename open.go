@@ -0,0 +1,319 @@
+package mergeips
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Djarvur/go-mergeips/records"
+)
+
+// Errors returned by Open and NewReader
+var (
+	ErrUnsupportedCompression = errors.New("unsupported compression")
+	ErrNoAddressColumn        = errors.New("no address column found in CSV")
+)
+
+// Compression magic numbers
+const (
+	MagicGzip  = "\x1f\x8b"
+	MagicBzip2 = "BZh"
+	MagicZstd  = "\x28\xb5\x2f\xfd"
+)
+
+// Decompressor returns the decompressing reader for the compressed stream.
+// The reader implementing io.Closer is closed by File.Close.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+type decompressor struct {
+	name  string
+	magic string
+	f     Decompressor
+}
+
+var (
+	decompressorsMu sync.RWMutex      // nolint: gochecknoglobals
+	decompressors   = []decompressor{ // nolint: gochecknoglobals
+		{name: "gzip", magic: MagicGzip, f: func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{name: "bzip2", magic: MagicBzip2, f: func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil }},
+		{name: "zstd", magic: MagicZstd, f: newZstdReader},
+	}
+)
+
+// zstdReader closes the decoder at the end of the stream or on Close, whichever is first,
+// releasing its goroutines
+type zstdReader struct {
+	d   *zstd.Decoder
+	err error
+}
+
+func newZstdReader(r io.Reader) (io.Reader, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+
+	return &zstdReader{d: d}, nil
+}
+
+// Read reads the decompressed data, closing the decoder on the first error, io.EOF included,
+// the error is returned by every Read after
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+
+	n, err := z.d.Read(p)
+	if err != nil {
+		z.err = err
+		z.d.Close()
+	}
+
+	return n, err
+}
+
+// Close closes the decoder, every Read after returns zstd.ErrDecoderClosed
+func (z *zstdReader) Close() error {
+	if z.err == nil {
+		z.err = zstd.ErrDecoderClosed
+	}
+
+	z.d.Close()
+
+	return nil
+}
+
+// RegisterDecompressor registers the decompressor for the streams starting with the magic provided,
+// replacing the one registered before for the same name, if any.
+// gzip, bzip2 and zstd are supported out of the box, registered with names "gzip", "bzip2" and "zstd".
+func RegisterDecompressor(name string, magic string, f Decompressor) {
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+
+	for i := range decompressors {
+		if decompressors[i].name == name {
+			decompressors[i] = decompressor{name: name, magic: magic, f: f}
+			return
+		}
+	}
+
+	decompressors = append(decompressors, decompressor{name: name, magic: magic, f: f})
+}
+
+// ContentType is the input content type detected by NewReader
+type ContentType int
+
+// Content types
+const (
+	ContentPlain ContentType = iota
+	ContentJSON
+	ContentCSV
+)
+
+// CSV column names recognized as the address one, case-insensitive
+var addressColumns = []string{records.DefaultField, "ip", "prefix", "network", "cidr", "subnet", "range"} // nolint: gochecknoglobals
+
+// sniffLen is the number of bytes the content type is detected by
+const sniffLen = 4096
+
+// File is the Scanner over the file opened with Open or the stream passed to NewFile
+type File struct {
+	Scanner
+	// Content is the content type detected
+	Content ContentType
+
+	closers []io.Closer
+}
+
+// Close releases the decompressor, if any, and closes the file opened with Open.
+// The stream passed to NewFile is not closed.
+func (f *File) Close() error {
+	var res error
+
+	for _, c := range f.closers {
+		if err := c.Close(); err != nil && res == nil {
+			res = err
+		}
+	}
+
+	return res
+}
+
+// Open opens the file for Scan. See NewReader for the compression and content types supported.
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := NewFile(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	file.closers = append(file.closers, f)
+
+	return file, nil
+}
+
+// NewFile is NewReader returning File, so the decompressor could be released with Close
+// before the end of the stream is reached, the stream itself is not closed
+func NewFile(r io.Reader) (*File, error) {
+	s, content, closer, err := newReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{Scanner: s, Content: content}
+	if closer != nil {
+		file.closers = append(file.closers, closer)
+	}
+
+	return file, nil
+}
+
+// ReadFile parses the file opened with Open to the list of net.IPNet
+func ReadFile(name string) ([]*net.IPNet, error) {
+	return Parser{}.ReadFile(name)
+}
+
+// ReadFile parses the file opened with Open to the list of net.IPNet
+func (p Parser) ReadFile(name string) ([]*net.IPNet, error) {
+	f, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res, err := p.Scan(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return res, nil
+}
+
+// NewReader returns the Scanner for the stream, decompressed if it starts with a registered magic,
+// gzip, bzip2 and zstd out of the box, see RegisterDecompressor.
+// The content is detected as:
+// JSON, array or NDJSON, if it starts with '[', '{' or '"', see records.NewJSONReader;
+// CSV if the first line contains a comma, with the first line as a header if no field of it is an address,
+// see records.NewCSVReader;
+// plain lines otherwise.
+// The decompressor is released at the end of the stream only, see NewFile to release it earlier.
+func NewReader(r io.Reader) (Scanner, ContentType, error) {
+	s, content, _, err := newReader(r)
+	return s, content, err
+}
+
+// newReader is NewReader returning the decompressor to be closed, if any
+func newReader(r io.Reader) (Scanner, ContentType, io.Closer, error) {
+	r, closer, err := decompress(r)
+	if err != nil {
+		return nil, ContentPlain, nil, err
+	}
+
+	s, content, err := newScanner(r)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+
+		return nil, content, nil, err
+	}
+
+	return s, content, closer, nil
+}
+
+// newScanner detects the content type of the decompressed stream
+func newScanner(r io.Reader) (Scanner, ContentType, error) {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, err := br.Peek(sniffLen)
+
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, ContentPlain, err
+	}
+
+	trimmed := bytes.TrimLeft(head, " \t\r\n")
+
+	if len(trimmed) > 0 && strings.IndexByte(`[{"`, trimmed[0]) >= 0 {
+		return records.NewJSONReader(br, records.Options{}), ContentJSON, nil
+	}
+
+	firstLine := string(head)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	if strings.Contains(firstLine, ",") {
+		options, err := csvOptions(strings.Split(strings.TrimSpace(firstLine), ","))
+		if err != nil {
+			return nil, ContentCSV, err
+		}
+
+		return records.NewCSVReader(br, options), ContentCSV, nil
+	}
+
+	return bufio.NewScanner(br), ContentPlain, nil
+}
+
+// decompress returns the decompressed stream and the decompressor to be closed, if it is io.Closer
+func decompress(r io.Reader) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(r)
+
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+
+	for _, d := range decompressors {
+		if magic, _ := br.Peek(len(d.magic)); string(magic) != d.magic {
+			continue
+		}
+
+		if d.f == nil {
+			return nil, nil, fmt.Errorf("%s: %w", d.name, ErrUnsupportedCompression)
+		}
+
+		dr, err := d.f(br)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		closer, _ := dr.(io.Closer)
+
+		return dr, closer, nil
+	}
+
+	return br, nil, nil
+}
+
+// csvOptions finds the address column by the first line fields:
+// the first field parsed as an address means there is no header,
+// otherwise the header field with one of the addressColumns names is the address one
+func csvOptions(fields []string) (records.Options, error) {
+	for i, field := range fields {
+		if _, err := Parse(strings.TrimSpace(field), false); err == nil {
+			return records.Options{Column: i}, nil
+		}
+	}
+
+	for _, name := range addressColumns {
+		for _, field := range fields {
+			if strings.EqualFold(field, name) {
+				return records.Options{Header: true, Field: field}, nil
+			}
+		}
+	}
+
+	return records.Options{}, fmt.Errorf("%q: %w", strings.Join(fields, ","), ErrNoAddressColumn)
+}
@@ -0,0 +1,244 @@
+package mergeips_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+var testOpenExpected = []string{"10.0.0.0/24", "2001:db8::1/128"} // nolint: gochecknoglobals
+
+func TestReadFile(t *testing.T) {
+	for _, name := range []string{"testdata/open/list.txt.bz2", "testdata/open/list.txt.zst", "testdata/merge-networks/nm1.in.gz"} {
+		nets, err := mergeips.ReadFile(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if len(nets) == 0 {
+			t.Errorf("%s: nothing read", name)
+		}
+	}
+
+	for _, name := range []string{"testdata/open/list.txt.bz2", "testdata/open/list.txt.zst"} {
+		nets, err := mergeips.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if diff := deep.Equal(netStrings(mergeips.Merge(nets)), testOpenExpected); diff != nil {
+			t.Errorf("%s: %v", name, diff)
+		}
+	}
+}
+
+func TestOpenClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mergeips")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var buf bytes.Buffer
+
+	w, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// large enough for the decoder to be stopped mid-stream, the bad line makes ReadFile stop early
+	w.Write([]byte("10.0.0.1\nbad\n")) // nolint: errcheck
+	for i := 0; i < 1<<16; i++ {
+		fmt.Fprintf(w, "10.%d.%d.0/24\n", i>>8, i&0xff)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	name := filepath.Join(dir, "list.txt.zst")
+	if err = ioutil.WriteFile(name, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		f, err := mergeips.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !f.Scan() || f.Text() != "10.0.0.1" {
+			t.Fatalf("got %q, %v", f.Text(), f.Err())
+		}
+
+		if err = f.Close(); err != nil {
+			t.Error(err)
+		}
+
+		if _, err = mergeips.ReadFile(name); !errors.Is(err, mergeips.ErrInputInvalid) {
+			t.Errorf("got error %v, expected %v", err, mergeips.ErrInputInvalid)
+		}
+	}
+
+	// the decoder goroutines exit asynchronously
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("got %d goroutines left, expected %d", after, before)
+	}
+}
+
+func TestRegisterDecompressor(t *testing.T) {
+	const magic = "\x00FAKE"
+
+	// fake stream: the magic followed by the plain content
+	fake := func(r io.Reader) (io.Reader, error) {
+		if _, err := io.ReadFull(r, make([]byte, len(magic))); err != nil {
+			return nil, err
+		}
+
+		return r, nil
+	}
+
+	mergeips.RegisterDecompressor("fake", magic, fake)
+
+	s, content, err := mergeips.NewReader(strings.NewReader(magic + "10.0.0.0/24\n2001:db8::1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nets, err := mergeips.Scan(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(netStrings(nets), testOpenExpected); diff != nil || content != mergeips.ContentPlain {
+		t.Errorf("%v, content %d", diff, content)
+	}
+
+	// the decompressor registered as nil makes the compression recognized but unsupported
+	mergeips.RegisterDecompressor("fake", magic, nil)
+
+	if _, _, err = mergeips.NewReader(strings.NewReader(magic + "10.0.0.0/24\n")); !errors.Is(err, mergeips.ErrUnsupportedCompression) {
+		t.Errorf("got error %v, expected %v", err, mergeips.ErrUnsupportedCompression)
+	}
+}
+
+type testNewReaderRow struct {
+	in       string
+	content  mergeips.ContentType
+	expected []string
+	err      error
+}
+
+var testNewReaderData = []testNewReaderRow{
+	{
+		in:       "10.0.0.0/25\n10.0.0.128-10.0.0.255\n2001:db8::1\n",
+		content:  mergeips.ContentPlain,
+		expected: testOpenExpected,
+	},
+	{
+		in:       "\n [\"10.0.0.0/25\", {\"address\": \"10.0.0.128/25\"}, \"2001:db8::1\"]",
+		content:  mergeips.ContentJSON,
+		expected: testOpenExpected,
+	},
+	{
+		in:       "{\"address\": \"10.0.0.0/24\"}\n\"2001:db8::1\"\n",
+		content:  mergeips.ContentJSON,
+		expected: testOpenExpected,
+	},
+	{
+		in:       "office,10.0.0.0/25\nvpn,10.0.0.128/25\nlab,2001:db8::1\n",
+		content:  mergeips.ContentCSV,
+		expected: testOpenExpected,
+	},
+	{
+		in:       "Name,CIDR,Comment\noffice,10.0.0.0/24,\"main, 2nd floor\"\nlab,2001:db8::1,\n",
+		content:  mergeips.ContentCSV,
+		expected: testOpenExpected,
+	},
+	{
+		in:      "name,comment\noffice,10.0.0.0/24\n",
+		content: mergeips.ContentCSV,
+		err:     mergeips.ErrNoAddressColumn,
+	},
+	{
+		in:       "",
+		content:  mergeips.ContentPlain,
+		expected: nil,
+	},
+}
+
+func TestNewReader(t *testing.T) {
+	for _, row := range testNewReaderData {
+		for _, compressed := range []bool{false, true} {
+			in := row.in
+			if compressed {
+				in = gzipString(in)
+			}
+
+			s, content, err := mergeips.NewReader(strings.NewReader(in))
+			if !errors.Is(err, row.err) || content != row.content {
+				t.Errorf("%q: got %d, %v, expected %d, %v", row.in, content, err, row.content, row.err)
+			}
+
+			if err != nil {
+				continue
+			}
+
+			nets, err := mergeips.Scan(s)
+			if err != nil {
+				t.Errorf("%q: %v", row.in, err)
+			}
+
+			if diff := deep.Equal(netStrings(mergeips.Merge(nets)), row.expected); diff != nil {
+				t.Errorf("%q: %v", row.in, diff)
+			}
+		}
+	}
+}
+
+func gzipString(s string) string {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	w.Write([]byte(s)) // nolint: errcheck
+	w.Close()          // nolint: errcheck
+
+	return buf.String()
+}
+
+func netStrings(nets []*net.IPNet) []string {
+	if len(nets) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(nets))
+
+	for _, n := range nets {
+		res = append(res, ipnet.String(n))
+	}
+
+	return res
+}
@@ -0,0 +1,68 @@
+package mergeips
+
+import (
+	"net"
+	"sort"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// List is the named list of prefixes, like the allow list of an environment
+type List struct {
+	Name string
+	Nets []*net.IPNet
+}
+
+// Overlap is the prefix common to two lists, Lists are their names in the order the lists are provided
+type Overlap struct {
+	Net   *net.IPNet
+	Lists [2]string
+}
+
+// Overlaps merges every list the same way Merge does and returns the prefixes common to every pair of the lists.
+// The common prefix is the prefix of one list included into, or equal to, the prefix of the other one.
+// The result is ordered the same way Merge result is, the pairs sharing the prefix are in the order the lists are provided.
+// All the lists are swept once, in order, so the time is not quadratic on the number of the lists.
+func Overlaps(lists []List) []Overlap {
+	var all []subnet.Sourced
+
+	for i, l := range lists {
+		for _, s := range subnet.Merge(subnet.FromIPNets(l.Nets)) {
+			all = append(all, subnet.Sourced{Subnet: s, Sources: []int{i}})
+		}
+	}
+
+	var (
+		res []Overlap
+		// the prefixes including the current one, from the biggest, at most one per list
+		stack []subnet.Sourced
+	)
+
+	for _, s := range subnet.SortSourced(all) {
+		for len(stack) > 0 && !stack[len(stack)-1].Include(s.Subnet) {
+			stack = stack[:len(stack)-1]
+		}
+
+		pairs := make([][2]int, 0, len(stack))
+
+		for _, outer := range stack {
+			if outer.Sources[0] < s.Sources[0] {
+				pairs = append(pairs, [2]int{outer.Sources[0], s.Sources[0]})
+			} else {
+				pairs = append(pairs, [2]int{s.Sources[0], outer.Sources[0]})
+			}
+		}
+
+		sort.Slice(pairs, func(i, j int) bool {
+			return pairs[i][0] < pairs[j][0] || pairs[i][0] == pairs[j][0] && pairs[i][1] < pairs[j][1]
+		})
+
+		for _, p := range pairs {
+			res = append(res, Overlap{Net: s.IPNet(), Lists: [2]string{lists[p[0]].Name, lists[p[1]].Name}})
+		}
+
+		stack = append(stack, s)
+	}
+
+	return res
+}
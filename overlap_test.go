@@ -0,0 +1,74 @@
+package mergeips_test
+
+import (
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testOverlapsRow struct {
+	lists    map[string][]string
+	order    []string
+	expected []string
+}
+
+var testOverlapsData = []testOverlapsRow{
+	{
+		lists: map[string][]string{
+			"prod":    {"10.0.0.0/16", "192.168.0.0/24", "2001:db8::/32"},
+			"staging": {"10.1.0.0/16", "192.168.0.0/25", "192.168.0.128/25", "2001:db8:1::/48"},
+			"bad":     {"10.0.1.2", "10.1.2.0/24", "192.168.0.7", "172.16.0.0/12"},
+		},
+		order: []string{"prod", "staging", "bad"},
+		expected: []string{
+			"10.0.1.2/32 prod bad",
+			"10.1.2.0/24 staging bad",
+			// staging is merged to 192.168.0.0/24 equal to prod one
+			"192.168.0.0/24 prod staging",
+			"192.168.0.7/32 prod bad",
+			"192.168.0.7/32 staging bad",
+			"2001:db8:1::/48 prod staging",
+		},
+	},
+	{
+		lists: map[string][]string{
+			"a": {"10.0.0.0/8"},
+			"b": {"11.0.0.0/8", "::/0"},
+			"c": {"12.0.0.0/8", "0.0.0.0/1"},
+		},
+		order:    []string{"a", "b", "c"},
+		expected: []string{"10.0.0.0/8 a c", "11.0.0.0/8 b c"},
+	},
+	{
+		lists: map[string][]string{
+			"a": {"10.0.0.0/8"},
+		},
+		order: []string{"a"},
+	},
+}
+
+func TestOverlaps(t *testing.T) {
+	for _, row := range testOverlapsData {
+		lists := make([]mergeips.List, 0, len(row.order))
+
+		for _, name := range row.order {
+			nets, err := mergeips.Scan(&stringSliceScanner{data: row.lists[name], next: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lists = append(lists, mergeips.List{Name: name, Nets: nets})
+		}
+
+		var got []string
+
+		for _, o := range mergeips.Overlaps(lists) {
+			got = append(got, o.Net.String()+" "+o.Lists[0]+" "+o.Lists[1])
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%v: %v", row.order, diff)
+		}
+	}
+}
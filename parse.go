@@ -3,20 +3,75 @@
 package mergeips
 
 import (
-	"bytes"
+	"context"
 	"errors"
-	"fmt"
+	"math/big"
 	"net"
 	"strings"
 
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/internal/parseerr"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
 	"github.com/Djarvur/go-mergeips/ipnet"
-
 	"github.com/Djarvur/go-mergeips/iprange"
 )
 
 // Errors
+// All the errors returned by Parse are *ParseError and match ErrInputInvalid with errors.Is,
+// the underlying ones are matched as well
 var (
-	ErrInputInvalid = errors.New("invalid input")
+	ErrInputInvalid  = parseerr.ErrInputInvalid
+	ErrMappedAddress = ipnet.ErrMappedAddress
+	ErrMixedFamily   = iprange.ErrMixedFamily
+	ErrReversedRange = iprange.ErrReversedRange
+	ErrCountOverflow = errors.New("address count exceeds the address family")
+	ErrNoAddresses   = errors.New("hostname has no addresses")
+	ErrZoned         = errors.New("zone identifier is not supported here, see ParseZoned")
+	ErrZonedIPv4     = errors.New("zone identifier for IPv4 address")
+)
+
+// ParseError describes the input rejected by Parse:
+// the input itself, the byte offset the problem starts at, the reason, the address family recognized
+// and the underlying error, if any
+type ParseError = parseerr.Error
+
+// ParseErrorReason tells what exactly is wrong with the input
+type ParseErrorReason = parseerr.Reason
+
+// Family is an address family the input was recognized as
+type Family = parseerr.Family
+
+// Parse error reasons
+const (
+	ReasonSyntax          = parseerr.ReasonSyntax
+	ReasonEmpty           = parseerr.ReasonEmpty
+	ReasonBadAddress      = parseerr.ReasonBadAddress
+	ReasonBadOctet        = parseerr.ReasonBadOctet
+	ReasonBadPrefixLength = parseerr.ReasonBadPrefixLength
+	ReasonPrefixTooLong   = parseerr.ReasonPrefixTooLong
+	ReasonNonCanonical    = parseerr.ReasonNonCanonical
+	ReasonMixedFamily     = parseerr.ReasonMixedFamily
+	ReasonReversedRange   = parseerr.ReasonReversedRange
+	ReasonMappedAddress   = parseerr.ReasonMappedAddress
+	ReasonBadCount        = parseerr.ReasonBadCount
+	ReasonCountOverflow   = parseerr.ReasonCountOverflow
+	ReasonLookup          = parseerr.ReasonLookup
+	ReasonZone            = parseerr.ReasonZone
+)
+
+// Address families
+const (
+	FamilyUnknown = parseerr.FamilyUnknown
+	FamilyIPv4    = parseerr.FamilyIPv4
+	FamilyIPv6    = parseerr.FamilyIPv6
+)
+
+// IPv4-mapped IPv6 addresses policies, see ipnet.MappedPolicy
+const (
+	MappedUnmap  = ipnet.MappedUnmap
+	MappedKeep   = ipnet.MappedKeep
+	MappedReject = ipnet.MappedReject
 )
 
 // Scanner is a simple interface to support Scan() function.
@@ -27,10 +82,50 @@ type Scanner interface {
 	Err() error
 }
 
+// Parser holds the parsing options
+// Zero value is ready to use and behaves the same way as Parse(s, false)
+type Parser struct {
+	// Strict makes CIDR form subnet defined with not-a-first address in the subnet an error
+	Strict bool
+	// Mapped defines how IPv4-mapped IPv6 addresses, like ::ffff:10.0.0.1, are handled
+	// Addresses written in IPv4 notation are always IPv4
+	Mapped ipnet.MappedPolicy
+	// SwapReversed makes the range with begin greater than end parsed as end-begin one
+	// instead of returning ErrReversedRange
+	SwapReversed bool
+	// Resolver, if not nil, expands hostnames, like api.partner.example, to /32 and /128 subnets of their addresses
+	// Hostnames are rejected if nil
+	Resolver Resolver
+}
+
 // Scan is used to parse source to the list of net.IPNet
 func Scan(s Scanner) (res []*net.IPNet, err error) {
+	return Parser{}.Scan(s)
+}
+
+// Parse parses a string to net.IPNet
+// String might be in 3 forms:
+// ip address itself, in v4 or v6 notation
+// CIDR subnet address, v4 or v6
+// IP adresses range, v4 or v6, in form begin-end
+// IP adresses block, v4 or v6, in form begin+count or "begin count N", count is not limited to powers of two
+// If strict is false CIDR form subnet could be defined with not-a-first addrsss in the subnet.
+// Otherwise the error will be returned
+// net.IPNet has no zone, so IPv6 zone identifiers, like fe80::1%eth0, are rejected with ErrZoned,
+// use Parser.ParseZoned to keep them
+func Parse(s string, strict bool) ([]*net.IPNet, error) {
+	return Parser{Strict: strict}.Parse(s)
+}
+
+// Scan is used to parse source to the list of net.IPNet
+func (p Parser) Scan(s Scanner) (res []*net.IPNet, err error) {
+	return p.ScanContext(context.Background(), s)
+}
+
+// ScanContext is Scan with the context for the hostname lookups
+func (p Parser) ScanContext(ctx context.Context, s Scanner) (res []*net.IPNet, err error) {
 	for s.Scan() {
-		subnets, err := Parse(s.Text(), false) // nolint: govet
+		subnets, err := p.ParseContext(ctx, s.Text()) // nolint: govet
 		if err != nil {
 			return nil, err
 		}
@@ -45,75 +140,196 @@ func Scan(s Scanner) (res []*net.IPNet, err error) {
 	return res, nil
 }
 
-// Parse parses a string to net.IPNet
-// String might be in 3 forms:
-// ip address itself, in v4 or v6 notation
-// CIDR subnet address, v4 or v6
-// IP adresses range, v4 or v6, in form begin-end
-// If strict is false CIDR form subnet could be defined with not-a-first addrsss in the subnet.
-// Otherwise the error will be returned
-func Parse(s string, strict bool) ([]*net.IPNet, error) {
+// Parse parses a string to net.IPNet, see Parse function for the forms supported
+// Hostnames are supported as well if Resolver is set
+func (p Parser) Parse(s string) ([]*net.IPNet, error) {
+	return p.ParseContext(context.Background(), s)
+}
+
+// ParseContext is Parse with the context for the hostname lookups
+func (p Parser) ParseContext(ctx context.Context, s string) ([]*net.IPNet, error) {
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		return nil, &ParseError{Input: s, Offset: i, Reason: ReasonZone, Family: parseerr.FamilyOf(s[:i]), Err: ErrZoned}
+	}
+
+	if p.Resolver != nil && isHostname(s) {
+		return p.parseHostname(ctx, s)
+	}
+
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		return p.parseCount(s, s[:i], i+1)
+	}
+
+	if i := strings.Index(s, countSeparator); i >= 0 {
+		return p.parseCount(s, s[:i], i+len(countSeparator))
+	}
+
 	fields := strings.Split(s, "/")
 
 	if len(fields) > 2 {
-		return nil, fmt.Errorf("%q: %w", s, ErrInputInvalid)
+		return nil, syntaxError(s, "/")
 	}
 
 	if len(fields) == 2 {
-		return parseCIDR(s, strict)
+		return p.parseCIDR(s)
 	}
 
 	fields = strings.Split(s, "-")
 
 	if len(fields) > 2 {
-		return nil, fmt.Errorf("%q: %w", s, ErrInputInvalid)
+		return nil, syntaxError(s, "-")
 	}
 
 	if len(fields) == 2 {
-		return parseRange(fields[0], fields[1])
+		return p.parseRange(s, fields[0], fields[1])
 	}
 
-	return parseIP(s)
+	return p.parseIP(s)
 }
 
 // Merge merges list of net.IPNet to the smallest possible set
+// IPv4 subnets go first in the result, then IPv6 ones, both sorted by address
 func Merge(nets []*net.IPNet) []*net.IPNet {
-	return ipnet.MergeSorted(ipnet.DedupSorted(ipnet.Sort(nets)))
+	return subnet.IPNets(subnet.Merge(subnet.FromIPNets(nets)))
 }
 
-func parseCIDR(s string, strict bool) ([]*net.IPNet, error) {
-	ip, n, err := net.ParseCIDR(s)
-	if err != nil || (strict && !ip.Equal(n.IP)) {
-		return nil, fmt.Errorf("%q: %w", s, ErrInputInvalid)
+func (p Parser) parseCIDR(s string) ([]*net.IPNet, error) {
+	sn, err := subnet.ParseCIDR(s, p.Strict)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := p.Mapped.IPNet(sn.IPNet())
+	if err != nil {
+		return nil, &ParseError{Input: s, Reason: ReasonMappedAddress, Family: FamilyIPv6, Err: err}
 	}
 
 	return []*net.IPNet{n}, nil
 }
 
-func parseRange(beginString string, endString string) ([]*net.IPNet, error) {
+func (p Parser) parseRange(s string, beginString string, endString string) ([]*net.IPNet, error) {
+	begin, err := p.parseAddr(s, beginString, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	endOffset := len(beginString) + 1
+
+	end, err := p.parseAddr(s, endString, endOffset)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := iprange.Options{Mapped: p.Mapped, SwapReversed: p.SwapReversed}.Merge(begin, end)
+	if err != nil {
+		return nil, rangeError(s, endOffset, begin, err)
+	}
+
+	return res, nil
+}
+
+// rangeError converts the iprange.Merge error to ParseError, endOffset is the offset of the range end in s
+func rangeError(s string, endOffset int, begin net.IP, err error) *ParseError {
+	switch {
+	case errors.Is(err, ErrMixedFamily):
+		return &ParseError{Input: s, Offset: endOffset, Reason: ReasonMixedFamily, Family: familyOf(begin), Err: err}
+	case errors.Is(err, ErrReversedRange):
+		return &ParseError{Input: s, Reason: ReasonReversedRange, Family: familyOf(begin), Err: err}
+	case errors.Is(err, ErrMappedAddress):
+		return &ParseError{Input: s, Reason: ReasonMappedAddress, Family: FamilyIPv6, Err: err}
+	}
+
+	return &ParseError{Input: s, Reason: ReasonBadAddress, Family: familyOf(begin), Err: err}
+}
+
+const countSeparator = " count "
+
+// parseCount parses the block of count addresses starting with beginString,
+// count is found in s at the offset provided
+func (p Parser) parseCount(s string, beginString string, countOffset int) ([]*net.IPNet, error) {
+	begin, err := p.parseAddr(s, beginString, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	family := familyOf(begin)
+	countString := s[countOffset:]
+
+	count, ok := big.NewInt(0).SetString(countString, 10)
+	if !ok || count.Sign() <= 0 || strings.TrimLeft(countString, "0123456789") != "" {
+		return nil, &ParseError{Input: s, Offset: countOffset, Reason: ReasonBadCount, Family: family}
+	}
+
 	var (
-		begin = net.ParseIP(beginString)
-		end   = net.ParseIP(endString)
+		bits     = len(begin) * 8
+		begin128 = int128.Uint128FromIP(begin)
+		maxEnd   = masks.Get(0, bits).Mask.Not()
 	)
 
-	if begin == nil || end == nil || (begin.To4() == nil) != (end.To4() == nil) || bytes.Compare(begin, end) > 0 {
-		return nil, fmt.Errorf("%q-%q: %w", beginString, endString, ErrInputInvalid)
+	hosts, err := int128.Uint128FromBigInt(count.Sub(count, big.NewInt(1)))
+
+	end, overflow := begin128.Add(hosts)
+	if err != nil || overflow || end.Cmp(maxEnd) > 0 {
+		return nil, &ParseError{Input: s, Offset: countOffset, Reason: ReasonCountOverflow, Family: family, Err: ErrCountOverflow}
+	}
+
+	// the policy is applied to begin already, the end is of the same family,
+	// so IPv6 block crossing into ::ffff:0:0/96 stays IPv6
+	res, err := iprange.Options{Mapped: ipnet.MappedKeep}.Merge(begin, end.IP(bits))
+	if err != nil {
+		return nil, rangeError(s, countOffset, begin, err)
+	}
+
+	return res, nil
+}
+
+func (p Parser) parseIP(s string) ([]*net.IPNet, error) {
+	ip, err := p.parseAddr(s, s, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	bits := len(ip) * 8
+
+	return []*net.IPNet{{IP: ip, Mask: net.CIDRMask(bits, bits)}}, nil
+}
+
+// parseAddr returns 4 bytes IP for IPv4 and 16 bytes one for IPv6,
+// IPv6 notation of IPv4-mapped addresses is handled according to the policy
+// addr is expected to be found in input at the offset provided
+func (p Parser) parseAddr(input string, addr string, offset int) (net.IP, error) {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return nil, parseerr.Addr(addr).Shift(input, offset)
 	}
 
-	return iprange.Merge(begin, end), nil
+	if !strings.Contains(addr, ":") {
+		return ip.To4(), nil
+	}
+
+	ip, err := p.Mapped.IP(ip)
+	if err != nil {
+		return nil, &ParseError{Input: input, Offset: offset, Reason: ReasonMappedAddress, Family: FamilyIPv6, Err: err}
+	}
+
+	return ip, nil
 }
 
-func parseIP(s string) ([]*net.IPNet, error) {
-	begin := net.ParseIP(s)
-	if begin == nil {
-		return nil, fmt.Errorf("%q: %w", s, ErrInputInvalid)
+func syntaxError(s string, sep string) *ParseError {
+	first := strings.Index(s, sep)
+
+	return &ParseError{
+		Input:  s,
+		Offset: first + 1 + strings.Index(s[first+1:], sep),
+		Reason: ReasonSyntax,
+		Family: parseerr.FamilyOf(s),
 	}
+}
 
-	bits := 128
-	if ipV4 := begin.To4(); ipV4 != nil {
-		bits = 32
-		begin = ipV4
+func familyOf(ip net.IP) Family {
+	if len(ip) == net.IPv4len {
+		return FamilyIPv4
 	}
 
-	return []*net.IPNet{{IP: begin, Mask: net.CIDRMask(bits, bits)}}, nil
+	return FamilyIPv6
 }
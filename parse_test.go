@@ -1,6 +1,7 @@
 package mergeips_test
 
 import (
+	"errors"
 	"net"
 	"testing"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/go-test/deep"
 
 	"github.com/Djarvur/go-mergeips/internal/subnet"
+	"github.com/Djarvur/go-mergeips/ipnet"
 )
 
 type testParseRow struct {
@@ -72,3 +74,221 @@ func parseCIDR(s string) *net.IPNet {
 
 	return n
 }
+
+type testParseMappedRow struct {
+	in       []string
+	policy   ipnet.MappedPolicy
+	expected []string
+	err      error
+}
+
+var testParseMappedData = []testParseMappedRow{
+	{
+		in:     []string{"10.0.0.0", "::ffff:10.0.0.1", "10.0.0.2/31", "::ffff:10.0.0.4/126", "::ffff:10.0.0.8-10.0.0.15"},
+		policy: mergeips.MappedUnmap,
+		expected: []string{
+			"10.0.0.0/28",
+		},
+	},
+	{
+		in:     []string{"10.0.0.0", "::ffff:10.0.0.1", "10.0.0.2/31", "::ffff:10.0.0.2/127"},
+		policy: mergeips.MappedKeep,
+		expected: []string{
+			"10.0.0.0/32",
+			"10.0.0.2/31",
+			"::ffff:10.0.0.1/128",
+			"::ffff:10.0.0.2/127",
+		},
+	},
+	{
+		in:     []string{"::ffff:10.0.0.0-::ffff:10.0.0.3", "::ffff:0.0.0.0/80"},
+		policy: mergeips.MappedKeep,
+		expected: []string{
+			"::/80",
+		},
+	},
+	{
+		in:     []string{"10.0.0.0", "::1", "::ffff:0:0/80"},
+		policy: mergeips.MappedReject,
+		expected: []string{
+			"10.0.0.0/32",
+			"::/80",
+		},
+	},
+	{
+		in:     []string{"10.0.0.0", "::ffff:10.0.0.1"},
+		policy: mergeips.MappedReject,
+		err:    mergeips.ErrMappedAddress,
+	},
+	{
+		in:     []string{"::ffff:10.0.0.0/120"},
+		policy: mergeips.MappedReject,
+		err:    mergeips.ErrMappedAddress,
+	},
+	{
+		in:     []string{"10.0.0.0-::ffff:10.0.0.1"},
+		policy: mergeips.MappedKeep,
+		err:    mergeips.ErrInputInvalid,
+	},
+	{
+		in:     []string{"::ffff:10.0.0.1"},
+		policy: mergeips.MappedReject,
+		err:    mergeips.ErrInputInvalid,
+	},
+}
+
+func TestParseMapped(t *testing.T) {
+	for _, row := range testParseMappedData {
+		nets, err := mergeips.Parser{Mapped: row.policy}.Scan(&stringSliceScanner{data: row.in, next: -1})
+		if !errors.Is(err, row.err) {
+			t.Errorf("%v: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		merged := mergeips.Merge(nets)
+		if diff := deep.Equal(netStrings(merged), row.expected); diff != nil {
+			t.Errorf("%v: got %v, expected %v: %v", row.in, merged, row.expected, diff)
+		}
+	}
+}
+
+type testParseRangeRow struct {
+	in       string
+	parser   mergeips.Parser
+	expected []*net.IPNet
+	err      error
+}
+
+var testParseRangeData = []testParseRangeRow{
+	{in: "0.0.0.0-::ffff", err: mergeips.ErrMixedFamily},
+	{in: "0.0.0.0-::ffff", err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.3-10.0.0.0", err: mergeips.ErrReversedRange},
+	{in: "10.0.0.3-10.0.0.0", err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.3-10.0.0.0", parser: mergeips.Parser{SwapReversed: true}, expected: []*net.IPNet{parseCIDR("10.0.0.0/30")}},
+	{in: "::ffff:10.0.0.0-10.0.0.3", parser: mergeips.Parser{Mapped: mergeips.MappedKeep}, err: mergeips.ErrMixedFamily},
+}
+
+type testParseCountRow struct {
+	in       string
+	mapped   ipnet.MappedPolicy
+	expected []string
+	err      error
+}
+
+var testParseCountData = []testParseCountRow{
+	{in: "10.0.0.0+256", expected: []string{"10.0.0.0/24"}},
+	{in: "10.0.0.0 count 300", expected: []string{"10.0.0.0/24", "10.0.1.0/27", "10.0.1.32/29", "10.0.1.40/30"}},
+	{in: "10.0.0.1+1", expected: []string{"10.0.0.1/32"}},
+	{in: "0.0.0.0+4294967296", expected: []string{"0.0.0.0/0"}},
+	{in: "255.255.255.255+1", expected: []string{"255.255.255.255/32"}},
+	{in: "255.255.255.255+2", err: mergeips.ErrCountOverflow},
+	{in: "::+340282366920938463463374607431768211456", expected: []string{"::/0"}},
+	{in: "::1+340282366920938463463374607431768211456", err: mergeips.ErrCountOverflow},
+	{in: "::+340282366920938463463374607431768211457", err: mergeips.ErrCountOverflow},
+	{in: "2001:db8::+3", expected: []string{"2001:db8::/127", "2001:db8::2/128"}},
+	{in: "::ffff:10.0.0.0+2", expected: []string{"10.0.0.0/31"}},
+	{in: "::ffff:10.0.0.0+2", mapped: mergeips.MappedKeep, expected: []string{"::ffff:10.0.0.0/127"}},
+	{in: "::ffff:10.0.0.0+2", mapped: mergeips.MappedReject, err: mergeips.ErrMappedAddress},
+	{in: "::fffe:ffff:ffff+2", expected: []string{"::fffe:ffff:ffff/128", "::ffff:0.0.0.0/128"}},
+	{in: "::fffe:ffff:ffff count 5", expected: []string{"::fffe:ffff:ffff/128", "::ffff:0.0.0.0/126"}},
+	{in: "::fffe:ffff:ffff+2", mapped: mergeips.MappedReject, expected: []string{"::fffe:ffff:ffff/128", "::ffff:0.0.0.0/128"}},
+	{in: "::fffe:ffff:ffff count 5", mapped: mergeips.MappedKeep, expected: []string{"::fffe:ffff:ffff/128", "::ffff:0.0.0.0/126"}},
+	{in: "10.0.0.0+", err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.0 count 0x10", err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.0  count 1", err: mergeips.ErrInputInvalid},
+}
+
+func TestParseCount(t *testing.T) {
+	for _, row := range testParseCountData {
+		nets, err := mergeips.Parser{Mapped: row.mapped}.Parse(row.in)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		var perr *mergeips.ParseError
+		if err != nil && (!errors.As(err, &perr) || !errors.Is(err, mergeips.ErrInputInvalid)) {
+			t.Errorf("%s: got error %v, expected %T", row.in, err, perr)
+		}
+
+		var got []string
+		for _, n := range nets {
+			got = append(got, ipnet.String(n))
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%s: %v", row.in, diff)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for _, row := range testParseRangeData {
+		nets, err := row.parser.Parse(row.in)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		if diff := deep.Equal(nets, row.expected); diff != nil {
+			t.Errorf("%s: got %v, expected %v: %v", row.in, nets, row.expected, diff)
+		}
+	}
+}
+
+type testParseErrorRow struct {
+	in     string
+	strict bool
+	reason mergeips.ParseErrorReason
+	offset int
+	family mergeips.Family
+}
+
+var testParseErrorData = []testParseErrorRow{
+	{in: "", reason: mergeips.ReasonEmpty, offset: 0, family: mergeips.FamilyUnknown},
+	{in: "host", reason: mergeips.ReasonBadAddress, offset: 0, family: mergeips.FamilyUnknown},
+	{in: "10.0.300.1", reason: mergeips.ReasonBadOctet, offset: 5, family: mergeips.FamilyIPv4},
+	{in: "10.0.0", reason: mergeips.ReasonBadAddress, offset: 6, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.1.2", reason: mergeips.ReasonBadAddress, offset: 8, family: mergeips.FamilyIPv4},
+	{in: "2001:db8::g1", reason: mergeips.ReasonBadAddress, offset: 10, family: mergeips.FamilyIPv6},
+	{in: "::ffff:10.0.0.256", reason: mergeips.ReasonBadOctet, offset: 14, family: mergeips.FamilyIPv6},
+	{in: "10.0.0.0/33", reason: mergeips.ReasonPrefixTooLong, offset: 9, family: mergeips.FamilyIPv4},
+	{in: "2001:db8::/129", reason: mergeips.ReasonPrefixTooLong, offset: 11, family: mergeips.FamilyIPv6},
+	{in: "10.0.0.0/x", reason: mergeips.ReasonBadPrefixLength, offset: 9, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.5/24", strict: true, reason: mergeips.ReasonNonCanonical, offset: 0, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0/24/8", reason: mergeips.ReasonSyntax, offset: 11, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0-10.0.0.1-10.0.0.2", reason: mergeips.ReasonSyntax, offset: 17, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0-10.0.0.x", reason: mergeips.ReasonBadOctet, offset: 16, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0-::1", reason: mergeips.ReasonMixedFamily, offset: 9, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.9-10.0.0.1", reason: mergeips.ReasonReversedRange, offset: 0, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0+0", reason: mergeips.ReasonBadCount, offset: 9, family: mergeips.FamilyIPv4},
+	{in: "10.0.0.0 count -1", reason: mergeips.ReasonBadCount, offset: 15, family: mergeips.FamilyIPv4},
+	{in: "::1+x", reason: mergeips.ReasonBadCount, offset: 4, family: mergeips.FamilyIPv6},
+	{in: "10.0.0.x+1", reason: mergeips.ReasonBadOctet, offset: 7, family: mergeips.FamilyIPv4},
+	{in: "255.255.255.0+257", reason: mergeips.ReasonCountOverflow, offset: 14, family: mergeips.FamilyIPv4},
+}
+
+func TestParseError(t *testing.T) {
+	for _, row := range testParseErrorData {
+		_, err := mergeips.Parse(row.in, row.strict)
+
+		var parseErr *mergeips.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got %v, expected *mergeips.ParseError", row.in, err)
+			continue
+		}
+
+		if !errors.Is(err, mergeips.ErrInputInvalid) {
+			t.Errorf("%q: got %v, expected to match %v", row.in, err, mergeips.ErrInputInvalid)
+		}
+
+		expected := testParseErrorRow{in: row.in, strict: row.strict, reason: row.reason, offset: row.offset, family: row.family}
+		got := testParseErrorRow{in: parseErr.Input, strict: row.strict, reason: parseErr.Reason, offset: parseErr.Offset, family: parseErr.Family}
+
+		// testParseErrorRow has unexported fields only, deep.Equal would not compare them
+		if got != expected {
+			t.Errorf("%q: got %+v, expected %+v", row.in, got, expected)
+		}
+	}
+}
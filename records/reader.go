@@ -0,0 +1,333 @@
+// Package records reads the addresses from and writes the merged prefixes to
+// the structured formats: JSON arrays, NDJSON, CSV and YAML
+package records
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Errors
+var (
+	ErrNoAddress        = errors.New("address field not found")
+	ErrUnsupportedValue = errors.New("unsupported value")
+)
+
+// DefaultField is the address field name used if Options.Field is empty
+const DefaultField = "address"
+
+// Record is the address read with the rest of the record fields
+type Record struct {
+	Address    string
+	Attributes map[string]string
+}
+
+// Options defines where the address is in the record
+type Options struct {
+	// Field is the address field name for JSON and YAML objects and for CSV with header,
+	// DefaultField if empty
+	Field string
+	// Column is 0-based address column for CSV without header
+	Column int
+	// Header makes the first CSV line the field names
+	Header bool
+	// Comma is CSV field delimiter, ',' if zero
+	Comma rune
+}
+
+// Reader reads the records one by one.
+// Compatible with mergeips.Scanner, so could be passed to mergeips.Scan directly
+type Reader struct {
+	next   func() (Record, error)
+	record Record
+	err    error
+}
+
+// Scan advances the Reader to the next record, returns false at the end of input or on error
+func (r *Reader) Scan() bool {
+	if r.err != nil {
+		return false
+	}
+
+	r.record, r.err = r.next()
+
+	return r.err == nil
+}
+
+// Text returns the address of the current record
+func (r *Reader) Text() string {
+	return r.record.Address
+}
+
+// Record returns the current record
+func (r *Reader) Record() Record {
+	return r.record
+}
+
+// Err returns the first error happened, nil at the end of input
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+
+	return r.err
+}
+
+// NewJSONReader returns the Reader for JSON array or NDJSON, the stream of JSON values.
+// Values are strings, the addresses, or objects with the address in Options.Field.
+func NewJSONReader(r io.Reader, options Options) *Reader {
+	var (
+		field = options.field()
+		br    = bufio.NewReader(r)
+		dec   *json.Decoder
+		array bool
+	)
+
+	next := func() (Record, error) {
+		if dec == nil {
+			first, err := peekNonSpace(br)
+			if err != nil {
+				return Record{}, err
+			}
+
+			dec = json.NewDecoder(br)
+			dec.UseNumber()
+
+			if array = first == '['; array {
+				if _, err = dec.Token(); err != nil {
+					return Record{}, err
+				}
+			}
+		}
+
+		if array && !dec.More() {
+			if _, err := dec.Token(); err != nil {
+				return Record{}, err
+			}
+
+			return Record{}, io.EOF
+		}
+
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return Record{}, err
+		}
+
+		return jsonRecord(value, field)
+	}
+
+	return &Reader{next: next}
+}
+
+// NewCSVReader returns the Reader for CSV.
+// The address is in Options.Field column if Options.Header is set, in Options.Column one otherwise.
+// The rest of the columns are the attributes, named by the header or by 0-based column index.
+func NewCSVReader(r io.Reader, options Options) *Reader {
+	var (
+		cr     = csv.NewReader(r)
+		names  []string
+		column = options.Column
+		line   int
+	)
+
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	if options.Comma != 0 {
+		cr.Comma = options.Comma
+	}
+
+	next := func() (Record, error) {
+		if line == 0 && options.Header {
+			header, err := cr.Read()
+			if err != nil {
+				return Record{}, err
+			}
+
+			names = append(names, header...)
+
+			if column = indexOf(names, options.field()); column < 0 {
+				return Record{}, fmt.Errorf("%q: %w", options.field(), ErrNoAddress)
+			}
+
+			line++
+		}
+
+		fields, err := cr.Read()
+		if err != nil {
+			return Record{}, err
+		}
+
+		line++
+
+		if column >= len(fields) {
+			return Record{}, fmt.Errorf("line %d: column %d: %w", line, column, ErrNoAddress)
+		}
+
+		return csvRecord(fields, names, column), nil
+	}
+
+	return &Reader{next: next}
+}
+
+// NewYAMLReader returns the Reader for YAML.
+// Every document is a list, items are strings, the addresses, or maps with the address in Options.Field.
+func NewYAMLReader(r io.Reader, options Options) *Reader {
+	var (
+		field = options.field()
+		dec   = yaml.NewDecoder(r)
+		items []interface{}
+	)
+
+	next := func() (Record, error) {
+		for len(items) == 0 {
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				return Record{}, err
+			}
+
+			if list, ok := doc.([]interface{}); ok {
+				items = list
+			} else if doc != nil {
+				items = []interface{}{doc}
+			}
+		}
+
+		item := items[0]
+		items = items[1:]
+
+		return yamlRecord(item, field)
+	}
+
+	return &Reader{next: next}
+}
+
+func (o Options) field() string {
+	if o.Field == "" {
+		return DefaultField
+	}
+
+	return o.Field
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			br.ReadByte() // nolint: errcheck
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+func jsonRecord(value interface{}, field string) (Record, error) {
+	switch v := value.(type) {
+	case string:
+		return Record{Address: v}, nil
+	case map[string]interface{}:
+		address, ok := v[field].(string)
+		if !ok {
+			return Record{}, fmt.Errorf("%q: %w", field, ErrNoAddress)
+		}
+
+		attrs := make(map[string]string, len(v)-1)
+
+		for name, value := range v {
+			if name != field {
+				attrs[name] = jsonString(value)
+			}
+		}
+
+		return Record{Address: address, Attributes: attrs}, nil
+	}
+
+	return Record{}, fmt.Errorf("%v: %w", value, ErrUnsupportedValue)
+}
+
+// jsonString returns strings as is and the rest of the values as JSON
+func jsonString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, _ := json.Marshal(value)
+
+	return string(b)
+}
+
+func csvRecord(fields []string, names []string, column int) Record {
+	res := Record{Address: fields[column], Attributes: make(map[string]string, len(fields)-1)}
+
+	for i, value := range fields {
+		if i == column {
+			continue
+		}
+
+		name := strconv.Itoa(i)
+		if i < len(names) {
+			name = names[i]
+		}
+
+		res.Attributes[name] = value
+	}
+
+	return res
+}
+
+func yamlRecord(value interface{}, field string) (Record, error) {
+	switch v := value.(type) {
+	case string:
+		return Record{Address: v}, nil
+	case map[interface{}]interface{}:
+		address, ok := v[field].(string)
+		if !ok {
+			return Record{}, fmt.Errorf("%q: %w", field, ErrNoAddress)
+		}
+
+		attrs := make(map[string]string, len(v)-1)
+
+		for name, value := range v {
+			if key := fmt.Sprint(name); key != field {
+				attrs[key] = yamlString(value)
+			}
+		}
+
+		return Record{Address: address, Attributes: attrs}, nil
+	}
+
+	return Record{}, fmt.Errorf("%v: %w", value, ErrUnsupportedValue)
+}
+
+// yamlString returns scalars formatted and the rest of the values as YAML
+func yamlString(value interface{}) string {
+	switch value.(type) {
+	case []interface{}, map[interface{}]interface{}:
+		b, _ := yaml.Marshal(value)
+		return string(b)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func indexOf(list []string, s string) int {
+	for i := range list {
+		if list[i] == s {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,256 @@
+package records_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/records"
+)
+
+type testReaderRow struct {
+	reader   func(r io.Reader, options records.Options) *records.Reader
+	input    string
+	options  records.Options
+	expected []records.Record
+	err      error
+}
+
+var testReaderData = []testReaderRow{
+	{
+		reader: records.NewJSONReader,
+		input:  ` ["10.0.0.0/8", {"address": "192.168.0.1", "note": "office", "id": 7}] `,
+		expected: []records.Record{
+			{Address: "10.0.0.0/8"},
+			{Address: "192.168.0.1", Attributes: map[string]string{"note": "office", "id": "7"}},
+		},
+	},
+	{
+		reader:  records.NewJSONReader,
+		input:   "{\"net\": \"10.0.0.0/8\"}\n{\"net\": \"::1\", \"tags\": [\"a\"]}\n\"1.2.3.4\"\n",
+		options: records.Options{Field: "net"},
+		expected: []records.Record{
+			{Address: "10.0.0.0/8", Attributes: map[string]string{}},
+			{Address: "::1", Attributes: map[string]string{"tags": `["a"]`}},
+			{Address: "1.2.3.4"},
+		},
+	},
+	{
+		reader:   records.NewJSONReader,
+		input:    "[]",
+		expected: nil,
+	},
+	{
+		reader:   records.NewJSONReader,
+		input:    `[{"ip": "10.0.0.0/8"}]`,
+		expected: nil,
+		err:      records.ErrNoAddress,
+	},
+	{
+		reader:   records.NewJSONReader,
+		input:    `[42]`,
+		expected: nil,
+		err:      records.ErrUnsupportedValue,
+	},
+	{
+		reader:  records.NewCSVReader,
+		input:   "name,address\noffice,192.168.0.0/24\nvpn,10.8.0.0/16\n",
+		options: records.Options{Header: true},
+		expected: []records.Record{
+			{Address: "192.168.0.0/24", Attributes: map[string]string{"name": "office"}},
+			{Address: "10.8.0.0/16", Attributes: map[string]string{"name": "vpn"}},
+		},
+	},
+	{
+		reader:  records.NewCSVReader,
+		input:   "office;192.168.0.0/24;x\nvpn;10.8.0.0/16\n",
+		options: records.Options{Column: 1, Comma: ';'},
+		expected: []records.Record{
+			{Address: "192.168.0.0/24", Attributes: map[string]string{"0": "office", "2": "x"}},
+			{Address: "10.8.0.0/16", Attributes: map[string]string{"0": "vpn"}},
+		},
+	},
+	{
+		reader:   records.NewCSVReader,
+		input:    "name,net\noffice,192.168.0.0/24\n",
+		options:  records.Options{Header: true},
+		expected: nil,
+		err:      records.ErrNoAddress,
+	},
+	{
+		reader:  records.NewCSVReader,
+		input:   "10.0.0.0/8,a\n\nb\n",
+		options: records.Options{Column: 1},
+		expected: []records.Record{
+			{Address: "a", Attributes: map[string]string{"0": "10.0.0.0/8"}},
+		},
+		err: records.ErrNoAddress,
+	},
+	{
+		reader: records.NewYAMLReader,
+		input:  "- 10.0.0.0/8\n- address: 2001:db8::/32\n  site: ams\n  ports: [80, 443]\n---\n- 1.2.3.4\n",
+		expected: []records.Record{
+			{Address: "10.0.0.0/8"},
+			{Address: "2001:db8::/32", Attributes: map[string]string{"site": "ams", "ports": "- 80\n- 443\n"}},
+			{Address: "1.2.3.4"},
+		},
+	},
+	{
+		reader:   records.NewYAMLReader,
+		input:    "- address: 10\n",
+		expected: nil,
+		err:      records.ErrNoAddress,
+	},
+}
+
+func TestReader(t *testing.T) {
+	for i, row := range testReaderData {
+		var (
+			r   = row.reader(strings.NewReader(row.input), row.options)
+			got []records.Record
+		)
+
+		for r.Scan() {
+			got = append(got, r.Record())
+		}
+
+		if !errors.Is(r.Err(), row.err) {
+			t.Errorf("%d: got error %v, expected %v", i, r.Err(), row.err)
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%d: %v", i, diff)
+		}
+	}
+}
+
+func TestReaderScan(t *testing.T) {
+	nets, err := mergeips.Scan(records.NewJSONReader(strings.NewReader(`["10.0.0.1-10.0.0.2", "::1"]`), records.Options{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(nets, []*net.IPNet{parseCIDR("10.0.0.1/32"), parseCIDR("10.0.0.2/32"), parseCIDR("::1/128")}); diff != nil {
+		t.Error(diff)
+	}
+}
+
+var testNets = []*net.IPNet{ // nolint: gochecknoglobals
+	parseCIDR("10.0.0.0/31"),
+	parseCIDR("2001:db8::/127"),
+	parseCIDR("::/0"),
+}
+
+type testWriteRow struct {
+	format   records.Format
+	fields   records.Fields
+	nets     []*net.IPNet
+	expected string
+}
+
+var testWriteData = []testWriteRow{
+	{
+		format:   records.FormatJSON,
+		nets:     testNets,
+		expected: `["10.0.0.0/31","2001:db8::/127","::/0"]` + "\n",
+	},
+	{
+		format:   records.FormatJSON,
+		nets:     nil,
+		expected: "[]\n",
+	},
+	{
+		format: records.FormatJSON,
+		fields: records.Fields{First: true, Size: true},
+		nets:   testNets[:2],
+		expected: `[{"address":"10.0.0.0/31","first":"10.0.0.0","size":2},` +
+			`{"address":"2001:db8::/127","first":"2001:db8::","size":2}]` + "\n",
+	},
+	{
+		format: records.FormatNDJSON,
+		fields: records.Fields{Field: "prefix", Last: true, Size: true},
+		nets:   testNets,
+		expected: `{"prefix":"10.0.0.0/31","last":"10.0.0.1","size":2}` + "\n" +
+			`{"prefix":"2001:db8::/127","last":"2001:db8::1","size":2}` + "\n" +
+			`{"prefix":"::/0","last":"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff","size":340282366920938463463374607431768211456}` + "\n",
+	},
+	{
+		format:   records.FormatCSV,
+		nets:     testNets[:1],
+		expected: "address\n10.0.0.0/31\n",
+	},
+	{
+		format:   records.FormatCSV,
+		fields:   records.Fields{First: true, Last: true, Size: true},
+		nets:     testNets[1:],
+		expected: "address,first,last,size\n2001:db8::/127,2001:db8::,2001:db8::1,2\n::/0,::,ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff,340282366920938463463374607431768211456\n",
+	},
+	{
+		format:   records.FormatYAML,
+		nets:     testNets[:2],
+		expected: "- 10.0.0.0/31\n- 2001:db8::/127\n",
+	},
+	{
+		format:   records.FormatYAML,
+		fields:   records.Fields{Size: true},
+		nets:     []*net.IPNet{testNets[0], testNets[2]},
+		expected: "- address: 10.0.0.0/31\n  size: 2\n- address: ::/0\n  size: \"340282366920938463463374607431768211456\"\n",
+	},
+}
+
+func TestWrite(t *testing.T) {
+	for i, row := range testWriteData {
+		var buf bytes.Buffer
+
+		if err := records.Write(&buf, row.nets, row.format, row.fields); err != nil {
+			t.Errorf("%d: %v", i, err)
+		}
+
+		if got := buf.String(); got != row.expected {
+			t.Errorf("%d: got %q, expected %q", i, got, row.expected)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	readers := map[records.Format]func(r io.Reader, options records.Options) *records.Reader{
+		records.FormatJSON:   records.NewJSONReader,
+		records.FormatNDJSON: records.NewJSONReader,
+		records.FormatCSV:    records.NewCSVReader,
+		records.FormatYAML:   records.NewYAMLReader,
+	}
+
+	for format, reader := range readers {
+		for _, fields := range []records.Fields{{}, {First: true, Last: true, Size: true}} {
+			var buf bytes.Buffer
+
+			if err := records.Write(&buf, testNets, format, fields); err != nil {
+				t.Fatal(err)
+			}
+
+			nets, err := mergeips.Scan(reader(&buf, records.Options{Header: true}))
+			if err != nil {
+				t.Fatalf("%d: %v", format, err)
+			}
+
+			if diff := deep.Equal(nets, testNets); diff != nil {
+				t.Errorf("%d: %v", format, diff)
+			}
+		}
+	}
+}
+
+func parseCIDR(s string) *net.IPNet {
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
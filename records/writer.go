@@ -0,0 +1,267 @@
+package records
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Djarvur/go-mergeips/formatter"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+// Format is the output format
+type Format int
+
+// Formats available
+const (
+	FormatJSON Format = iota
+	FormatNDJSON
+	FormatCSV
+	FormatYAML
+)
+
+// Output field names
+const (
+	FieldFirst = "first"
+	FieldLast  = "last"
+	FieldSize  = "size"
+)
+
+// Fields are the optional fields written with every prefix
+// If none of them set, JSON, NDJSON and YAML prefixes are written as plain strings, not objects
+type Fields struct {
+	// Field is the prefix field name, DefaultField if empty
+	Field string
+	// First adds the first address of the prefix
+	First bool
+	// Last adds the last address of the prefix
+	Last bool
+	// Size adds the number of addresses in the prefix
+	Size bool
+}
+
+// Write writes the prefixes in the format provided, in the order provided
+func Write(w io.Writer, nets []*net.IPNet, format Format, fields Fields) error {
+	bw := bufio.NewWriter(w)
+
+	var err error
+
+	switch format {
+	case FormatJSON:
+		err = writeJSON(bw, nets, fields)
+	case FormatNDJSON:
+		err = writeNDJSON(bw, nets, fields)
+	case FormatCSV:
+		err = writeCSV(bw, nets, fields)
+	case FormatYAML:
+		err = writeYAML(bw, nets, fields)
+	default:
+		err = fmt.Errorf("format %d: %w", format, ErrUnsupportedValue)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+func (f Fields) plain() bool {
+	return !f.First && !f.Last && !f.Size
+}
+
+func (f Fields) field() string {
+	return Options{Field: f.Field}.field()
+}
+
+// names returns the field names, the prefix first
+func (f Fields) names() []string {
+	res := []string{f.field()}
+
+	if f.First {
+		res = append(res, FieldFirst)
+	}
+
+	if f.Last {
+		res = append(res, FieldLast)
+	}
+
+	if f.Size {
+		res = append(res, FieldSize)
+	}
+
+	return res
+}
+
+// values returns the field values of the prefix in the names order
+func (f Fields) values(n *net.IPNet) []interface{} {
+	var (
+		p   = formatter.PrefixOf(n, 0)
+		res = []interface{}{p.Prefix}
+	)
+
+	if f.First {
+		res = append(res, p.First.String())
+	}
+
+	if f.Last {
+		res = append(res, p.Last.String())
+	}
+
+	if f.Size {
+		res = append(res, p.Count)
+	}
+
+	return res
+}
+
+func writeJSON(w io.Writer, nets []*net.IPNet, fields Fields) error {
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	for i, n := range nets {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+
+		b, err := jsonValue(n, fields)
+		if err != nil {
+			return err
+		}
+
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "]\n")
+
+	return err
+}
+
+func writeNDJSON(w io.Writer, nets []*net.IPNet, fields Fields) error {
+	for _, n := range nets {
+		b, err := jsonValue(n, fields)
+		if err != nil {
+			return err
+		}
+
+		if _, err = w.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jsonValue returns the prefix as JSON string or object, keeping the fields order
+func jsonValue(n *net.IPNet, fields Fields) ([]byte, error) {
+	if fields.plain() {
+		return json.Marshal(ipnet.String(n))
+	}
+
+	var (
+		names  = fields.names()
+		values = fields.values(n)
+		res    = []byte{'{'}
+	)
+
+	for i := range names {
+		name, err := json.Marshal(names[i])
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(values[i])
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			res = append(res, ',')
+		}
+
+		res = append(append(append(res, name...), ':'), value...)
+	}
+
+	return append(res, '}'), nil
+}
+
+func writeCSV(w io.Writer, nets []*net.IPNet, fields Fields) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(fields.names()); err != nil {
+		return err
+	}
+
+	for _, n := range nets {
+		values := fields.values(n)
+		record := make([]string, 0, len(values))
+
+		for _, v := range values {
+			record = append(record, fmt.Sprint(v))
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func writeYAML(w io.Writer, nets []*net.IPNet, fields Fields) error {
+	var (
+		names = fields.names()
+		list  = make([]interface{}, 0, len(nets))
+	)
+
+	for _, n := range nets {
+		if fields.plain() {
+			list = append(list, ipnet.String(n))
+			continue
+		}
+
+		values := fields.values(n)
+		item := make(yaml.MapSlice, 0, len(names))
+
+		for i := range names {
+			item = append(item, yaml.MapItem{Key: names[i], Value: yamlValue(values[i])})
+		}
+
+		list = append(list, item)
+	}
+
+	b, err := yaml.Marshal(list)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+
+	return err
+}
+
+// yamlValue makes the sizes fitting uint64 YAML integers, the bigger ones are written as strings
+func yamlValue(v interface{}) interface{} {
+	if size, ok := v.(*big.Int); ok {
+		if size.IsUint64() {
+			return size.Uint64()
+		}
+
+		return size.String()
+	}
+
+	return v
+}
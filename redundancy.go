@@ -0,0 +1,59 @@
+package mergeips
+
+import (
+	"net"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Shadowed is an input entry removed by merging as fully covered by another one
+type Shadowed struct {
+	Entry Entry
+	By    Entry
+}
+
+// Combined is a merged subnet made of several input entries
+type Combined struct {
+	Net     *net.IPNet
+	Entries []Entry
+}
+
+// RedundancyReport lists the input entries could be simplified by hand
+type RedundancyReport struct {
+	Shadowed []Shadowed
+	Combined []Combined
+}
+
+// Redundancy reports the entries removed by merging as included into another entry,
+// with the entry including it, and the groups of entries merged together.
+// Groups made of a single source, like a range, are not reported.
+// Both lists are ordered by address.
+func Redundancy(entries []Entry) (report RedundancyReport) {
+	sourced := subnet.SortSourced(sourcedEntries(entries))
+
+	deduped := dedupKept(
+		sourced,
+		func(kept, removed subnet.Sourced) {
+			report.Shadowed = append(
+				report.Shadowed,
+				Shadowed{Entry: entries[removed.Sources[0]], By: entries[kept.Sources[0]]},
+			)
+		},
+	)
+
+	for _, group := range subnet.MergeSortedSourced(deduped) {
+		if len(entrySources(entries, group.Sources)) < 2 {
+			continue
+		}
+
+		combined := Combined{Net: group.IPNet(), Entries: make([]Entry, 0, len(group.Sources))}
+
+		for _, i := range group.Sources {
+			combined.Entries = append(combined.Entries, entries[i])
+		}
+
+		report.Combined = append(report.Combined, combined)
+	}
+
+	return report
+}
@@ -0,0 +1,36 @@
+package mergeips_test
+
+import (
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+func TestRedundancy(t *testing.T) {
+	entries, err := mergeips.ScanSources(
+		&stringSliceScanner{
+			data: []string{"10.0.1.0/24", "10.0.0.0/25", "10.0.0.0/24", "10.0.0.0/24", "10.0.2.0-10.0.3.255"},
+			next: -1,
+		},
+		"list",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := mergeips.Redundancy(entries)
+	expected := mergeips.RedundancyReport{
+		Shadowed: []mergeips.Shadowed{
+			{Entry: entries[3], By: entries[2]},
+			{Entry: entries[1], By: entries[2]},
+		},
+		Combined: []mergeips.Combined{
+			{Net: parseCIDR("10.0.0.0/22"), Entries: []mergeips.Entry{entries[0], entries[2], entries[4]}},
+		},
+	}
+
+	if diff := deep.Equal(report, expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", report, expected, diff)
+	}
+}
@@ -0,0 +1,138 @@
+package mergeips
+
+import (
+	"net"
+	"sort"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Policy is the list of prefixes with the action applied to them, like deny or allow.
+// Where the policies overlap the one of the highest Priority wins, the first one provided wins the tie.
+type Policy struct {
+	Action   string
+	Priority int
+	Nets     []*net.IPNet
+}
+
+// Rule is the prefix with the action resolved for it
+type Rule struct {
+	Net    *net.IPNet
+	Action string
+}
+
+// Resolve resolves the policies into the flat table of the non-overlapping prefixes with the winning actions.
+// The table is minimal: the neighbouring ranges of the same action are merged before split into the prefixes.
+// The space covered by none of the policies is not in the table.
+// The table is ordered the same way Merge result is.
+func Resolve(policies []Policy) (res []Rule) {
+	for _, bits := range []int{32, 128} {
+		for _, r := range resolveFamily(policies, bits) {
+			for _, s := range subnet.FromRange(r.first, r.last, bits) {
+				res = append(res, Rule{Net: s.IPNet(), Action: policies[r.policy].Action})
+			}
+		}
+	}
+
+	return res
+}
+
+// boundary is the point the policy starts or stops to be applied at
+type boundary struct {
+	at     int128.Uint128
+	policy int
+	start  bool
+}
+
+// resolvedRange is the range of addresses the policy wins for
+type resolvedRange struct {
+	first  int128.Uint128
+	last   int128.Uint128
+	policy int
+}
+
+// resolveFamily sweeps the boundaries of the policies prefixes of the family
+// and returns the ranges of the winning policies, the neighbours of the same action merged
+func resolveFamily(policies []Policy, bits int) (res []resolvedRange) {
+	var (
+		boundaries []boundary
+		one        = int128.Uint128FromUint64s(0, 1)
+	)
+
+	for i, p := range policies {
+		for _, s := range subnet.Merge(subnet.FromIPNets(p.Nets)) {
+			if s.Bits != bits {
+				continue
+			}
+
+			boundaries = append(boundaries, boundary{at: s.IP, policy: i, start: true})
+
+			// the prefix at the very end of the address space never stops
+			if next, overflow := s.IP.RangeEnd(s.Mask().Mask).Add(one); !overflow {
+				boundaries = append(boundaries, boundary{at: next, policy: i})
+			}
+		}
+	}
+
+	sort.SliceStable(boundaries, func(i, j int) bool { return boundaries[i].at.Cmp(boundaries[j].at) < 0 })
+
+	var (
+		active = make([]bool, len(policies))
+		winner = -1
+		first  int128.Uint128
+	)
+
+	for i := 0; i < len(boundaries); {
+		at := boundaries[i].at
+
+		if winner >= 0 {
+			last, _ := at.Sub(one)
+			res = appendResolved(res, policies, resolvedRange{first: first, last: last, policy: winner})
+		}
+
+		for ; i < len(boundaries) && boundaries[i].at.Cmp(at) == 0; i++ {
+			active[boundaries[i].policy] = boundaries[i].start
+		}
+
+		first = at
+		winner = resolveWinner(policies, active)
+	}
+
+	if winner >= 0 {
+		last := masks.Get(0, bits).Mask.Not()
+		res = appendResolved(res, policies, resolvedRange{first: first, last: last, policy: winner})
+	}
+
+	return res
+}
+
+// resolveWinner returns the index of the active policy of the highest priority, -1 if there is no active ones
+func resolveWinner(policies []Policy, active []bool) int {
+	winner := -1
+
+	for i, p := range policies {
+		if active[i] && (winner < 0 || p.Priority > policies[winner].Priority) {
+			winner = i
+		}
+	}
+
+	return winner
+}
+
+// appendResolved appends r to the list, merging it to the last range if they are neighbours of the same action
+func appendResolved(res []resolvedRange, policies []Policy, r resolvedRange) []resolvedRange {
+	if len(res) > 0 {
+		prev := &res[len(res)-1]
+
+		if next, _ := prev.last.Add(int128.Uint128FromUint64s(0, 1)); next.Cmp(r.first) == 0 &&
+			policies[prev.policy].Action == policies[r.policy].Action {
+			prev.last = r.last
+
+			return res
+		}
+	}
+
+	return append(res, r)
+}
@@ -0,0 +1,121 @@
+package mergeips_test
+
+import (
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testResolvePolicy struct {
+	action   string
+	priority int
+	nets     []string
+}
+
+type testResolveRow struct {
+	policies []testResolvePolicy
+	expected []string
+}
+
+var testResolveData = []testResolveRow{
+	{
+		policies: []testResolvePolicy{
+			{action: "default", priority: 0, nets: []string{"0.0.0.0/0", "2001:db8::/31"}},
+			{action: "allow", priority: 1, nets: []string{"10.0.0.0/8", "2001:db8::/32"}},
+			{action: "deny", priority: 2, nets: []string{"10.0.0.0/9", "10.128.0.1", "2001:db8::/33"}},
+		},
+		expected: []string{
+			"0.0.0.0/5 default",
+			"8.0.0.0/7 default",
+			"10.0.0.0/9 deny",
+			"10.128.0.0/32 allow",
+			"10.128.0.1/32 deny",
+			"10.128.0.2/31 allow",
+			"10.128.0.4/30 allow",
+			"10.128.0.8/29 allow",
+			"10.128.0.16/28 allow",
+			"10.128.0.32/27 allow",
+			"10.128.0.64/26 allow",
+			"10.128.0.128/25 allow",
+			"10.128.1.0/24 allow",
+			"10.128.2.0/23 allow",
+			"10.128.4.0/22 allow",
+			"10.128.8.0/21 allow",
+			"10.128.16.0/20 allow",
+			"10.128.32.0/19 allow",
+			"10.128.64.0/18 allow",
+			"10.128.128.0/17 allow",
+			"10.129.0.0/16 allow",
+			"10.130.0.0/15 allow",
+			"10.132.0.0/14 allow",
+			"10.136.0.0/13 allow",
+			"10.144.0.0/12 allow",
+			"10.160.0.0/11 allow",
+			"10.192.0.0/10 allow",
+			"11.0.0.0/8 default",
+			"12.0.0.0/6 default",
+			"16.0.0.0/4 default",
+			"32.0.0.0/3 default",
+			"64.0.0.0/2 default",
+			"128.0.0.0/1 default",
+			"2001:db8::/33 deny",
+			"2001:db8:8000::/33 allow",
+			"2001:db9::/32 default",
+		},
+	},
+	{
+		// the neighbours of the same action are merged, uncovered space is skipped
+		policies: []testResolvePolicy{
+			{action: "allow", priority: 1, nets: []string{"10.0.0.0/25", "10.0.1.0/24"}},
+			{action: "allow", priority: 5, nets: []string{"10.0.0.128/25"}},
+			{action: "deny", priority: 3, nets: []string{"10.0.1.0/25"}},
+		},
+		expected: []string{"10.0.0.0/24 allow", "10.0.1.0/25 deny", "10.0.1.128/25 allow"},
+	},
+	{
+		// the tie is won by the first policy
+		policies: []testResolvePolicy{
+			{action: "allow", nets: []string{"10.0.0.0/24", "255.255.255.255", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff"}},
+			{action: "deny", nets: []string{"10.0.0.0/23", "255.255.255.254/31", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/126"}},
+		},
+		expected: []string{
+			"10.0.0.0/24 allow",
+			"10.0.1.0/24 deny",
+			"255.255.255.254/32 deny",
+			"255.255.255.255/32 allow",
+			"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffc/127 deny",
+			"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe/128 deny",
+			"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff/128 allow",
+		},
+	},
+	{
+		policies: nil,
+		expected: nil,
+	},
+}
+
+func TestResolve(t *testing.T) {
+	for i, row := range testResolveData {
+		policies := make([]mergeips.Policy, 0, len(row.policies))
+
+		for _, p := range row.policies {
+			nets, err := mergeips.Scan(&stringSliceScanner{data: p.nets, next: -1})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			policies = append(policies, mergeips.Policy{Action: p.action, Priority: p.priority, Nets: nets})
+		}
+
+		var got []string
+
+		for _, r := range mergeips.Resolve(policies) {
+			got = append(got, r.Net.String()+" "+r.Action)
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%d: %v", i, diff)
+		}
+	}
+}
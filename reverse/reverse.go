@@ -0,0 +1,163 @@
+// Package reverse maps the merged prefixes to the reverse DNS zones, in-addr.arpa and ip6.arpa,
+// to be delegated for them
+package reverse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// ErrNoNameServers is returned by WriteStubs called with no name servers
+var ErrNoNameServers = errors.New("no name servers")
+
+// Zone is the reverse zone covering the prefix.
+// The IPv4 prefixes longer than /24 are covered by RFC 2317 classless zones, like 64/26.2.0.192.in-addr.arpa.,
+// delegated from the /24 zone, Parent, with CNAME record for every address of the prefix.
+type Zone struct {
+	Name   string
+	Net    *net.IPNet
+	Parent string
+}
+
+// Classless returns true for RFC 2317 classless zone
+func (z Zone) Classless() bool {
+	return z.Parent != ""
+}
+
+// Zones merges the prefixes the same way mergeips.Merge does and returns the minimal list of the reverse zones
+// covering them, in the order of the prefixes merged.
+// IPv4 prefixes are split at octet boundaries and IPv6 ones at nibble boundaries,
+// like 10.0.0.0/15 into 0.10.in-addr.arpa. and 1.10.in-addr.arpa.
+func Zones(nets []*net.IPNet) []Zone {
+	var res []Zone
+
+	for _, s := range subnet.Merge(subnet.FromIPNets(nets)) {
+		step := 4
+		if s.Bits == 32 {
+			step = 8
+
+			if s.Ones > 24 {
+				res = append(res, classlessZone(s))
+				continue
+			}
+		}
+
+		it, err := s.Split((s.Ones + step - 1) / step * step)
+		if err != nil {
+			panic("unreachable reached")
+		}
+
+		for it.Next() {
+			res = append(res, Zone{Name: zoneName(it.Subnet()), Net: it.Subnet().IPNet()})
+		}
+	}
+
+	return res
+}
+
+// WriteNames writes the names of the zones, one per line
+func WriteNames(w io.Writer, zones []Zone) error {
+	bw := bufio.NewWriter(w)
+
+	for _, z := range zones {
+		if _, err := fmt.Fprintln(bw, z.Name); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteStubs writes the delegation records of the zones to be put to the parent zones, in the master file format:
+// NS records of every zone, and CNAME records of every address for RFC 2317 classless zones
+func WriteStubs(w io.Writer, zones []Zone, nameServers []string) error {
+	if len(nameServers) == 0 {
+		return ErrNoNameServers
+	}
+
+	bw := bufio.NewWriter(w)
+
+	for _, z := range zones {
+		fmt.Fprintf(bw, "; %s\n", z.Net)
+
+		for _, ns := range nameServers {
+			fmt.Fprintf(bw, "%s\tIN\tNS\t%s\n", z.Name, fqdn(ns))
+		}
+
+		if z.Classless() {
+			writeCNAMEs(bw, z)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// classlessZone returns RFC 2317 zone of IPv4 prefix longer than /24
+func classlessZone(s subnet.Subnet) Zone {
+	parent, err := s.Supernet(24)
+	if err != nil {
+		panic("unreachable reached")
+	}
+
+	name := zoneName(parent)
+
+	return Zone{
+		Name:   strconv.Itoa(int(s.IP.IP(32)[3])) + "/" + strconv.Itoa(s.Ones) + "." + name,
+		Net:    s.IPNet(),
+		Parent: name,
+	}
+}
+
+// writeCNAMEs writes CNAME record of every address of the classless zone
+func writeCNAMEs(w io.Writer, z Zone) {
+	var (
+		first = int(z.Net.IP.To4()[3])
+		ones  = subnet.FromIPNet(z.Net).Ones
+	)
+
+	for host := first; host < first+1<<(32-ones); host++ {
+		fmt.Fprintf(w, "%d.%s\tIN\tCNAME\t%d.%s\n", host, z.Parent, host, z.Name)
+	}
+}
+
+// zoneName returns the reverse zone name of the prefix at octet boundary for IPv4 and nibble one for IPv6
+func zoneName(s subnet.Subnet) string {
+	var (
+		ip     = s.IP.IP(s.Bits)
+		labels []string
+	)
+
+	if s.Bits == 32 {
+		for i := 0; i < s.Ones/8; i++ {
+			labels = append([]string{strconv.Itoa(int(ip[i]))}, labels...)
+		}
+
+		return strings.Join(append(labels, "in-addr.arpa."), ".")
+	}
+
+	for i := 0; i < s.Ones/4; i++ {
+		nibble := ip[i/2] >> 4
+		if i%2 == 1 {
+			nibble = ip[i/2] & 0xf
+		}
+
+		labels = append([]string{strconv.FormatUint(uint64(nibble), 16)}, labels...)
+	}
+
+	return strings.Join(append(labels, "ip6.arpa."), ".")
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+
+	return name + "."
+}
@@ -0,0 +1,143 @@
+package reverse_test
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips/reverse"
+)
+
+type testZonesRow struct {
+	in       []string
+	expected []string
+}
+
+var testZonesData = []testZonesRow{
+	{
+		in:       []string{"10.0.0.0/16", "10.1.0.0/16", "192.0.2.0/24"},
+		expected: []string{"0.10.in-addr.arpa. 10.0.0.0/16", "1.10.in-addr.arpa. 10.1.0.0/16", "2.0.192.in-addr.arpa. 192.0.2.0/24"},
+	},
+	{
+		in:       []string{"10.0.0.0/22", "0.0.0.0/0"},
+		expected: []string{"in-addr.arpa. 0.0.0.0/0"},
+	},
+	{
+		in: []string{"172.16.0.0/22", "192.0.2.64/26", "192.0.2.128/25", "198.51.100.1"},
+		expected: []string{
+			"0.16.172.in-addr.arpa. 172.16.0.0/24",
+			"1.16.172.in-addr.arpa. 172.16.1.0/24",
+			"2.16.172.in-addr.arpa. 172.16.2.0/24",
+			"3.16.172.in-addr.arpa. 172.16.3.0/24",
+			"64/26.2.0.192.in-addr.arpa. 192.0.2.64/26 2.0.192.in-addr.arpa.",
+			"128/25.2.0.192.in-addr.arpa. 192.0.2.128/25 2.0.192.in-addr.arpa.",
+			"1/32.100.51.198.in-addr.arpa. 198.51.100.1/32 100.51.198.in-addr.arpa.",
+		},
+	},
+	{
+		in: []string{"2001:db8::/32", "2001:db8:abc0::/46", "::1"},
+		expected: []string{
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.ip6.arpa. ::1/128",
+			"8.b.d.0.1.0.0.2.ip6.arpa. 2001:db8::/32",
+		},
+	},
+	{
+		in: []string{"2001:db8:abc0::/46", "8000::/2"},
+		expected: []string{
+			"0.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa. 2001:db8:abc0::/48",
+			"1.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa. 2001:db8:abc1::/48",
+			"2.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa. 2001:db8:abc2::/48",
+			"3.c.b.a.8.b.d.0.1.0.0.2.ip6.arpa. 2001:db8:abc3::/48",
+			"8.ip6.arpa. 8000::/4",
+			"9.ip6.arpa. 9000::/4",
+			"a.ip6.arpa. a000::/4",
+			"b.ip6.arpa. b000::/4",
+		},
+	},
+}
+
+func TestZones(t *testing.T) {
+	for _, row := range testZonesData {
+		var got []string
+
+		for _, z := range reverse.Zones(parseCIDRs(row.in)) {
+			s := z.Name + " " + z.Net.String()
+			if z.Classless() {
+				s += " " + z.Parent
+			}
+
+			got = append(got, s)
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%v: %v", row.in, diff)
+		}
+	}
+}
+
+const testStubs = `; 192.0.2.0/24
+2.0.192.in-addr.arpa.	IN	NS	ns1.example.net.
+2.0.192.in-addr.arpa.	IN	NS	ns2.example.net.
+; 198.51.100.4/30
+4/30.100.51.198.in-addr.arpa.	IN	NS	ns1.example.net.
+4/30.100.51.198.in-addr.arpa.	IN	NS	ns2.example.net.
+4.100.51.198.in-addr.arpa.	IN	CNAME	4.4/30.100.51.198.in-addr.arpa.
+5.100.51.198.in-addr.arpa.	IN	CNAME	5.4/30.100.51.198.in-addr.arpa.
+6.100.51.198.in-addr.arpa.	IN	CNAME	6.4/30.100.51.198.in-addr.arpa.
+7.100.51.198.in-addr.arpa.	IN	CNAME	7.4/30.100.51.198.in-addr.arpa.
+`
+
+func TestWriteStubs(t *testing.T) {
+	zones := reverse.Zones(parseCIDRs([]string{"192.0.2.0/24", "198.51.100.4/30"}))
+
+	var buf bytes.Buffer
+
+	if err := reverse.WriteStubs(&buf, zones, []string{"ns1.example.net", "ns2.example.net."}); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(buf.String(), testStubs); diff != nil {
+		t.Error(diff)
+	}
+
+	if err := reverse.WriteStubs(&buf, zones, nil); !errors.Is(err, reverse.ErrNoNameServers) {
+		t.Errorf("got error %v, expected %v", err, reverse.ErrNoNameServers)
+	}
+
+	buf.Reset()
+
+	if err := reverse.WriteNames(&buf, zones); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := buf.String(); s != "2.0.192.in-addr.arpa.\n4/30.100.51.198.in-addr.arpa.\n" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func parseCIDRs(list []string) []*net.IPNet {
+	res := make([]*net.IPNet, 0, len(list))
+
+	for _, s := range list {
+		if _, n, err := net.ParseCIDR(s); err == nil {
+			res = append(res, n)
+			continue
+		}
+
+		ip := net.ParseIP(s)
+		if ip == nil {
+			panic(s)
+		}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+
+		res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+	}
+
+	return res
+}
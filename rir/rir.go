@@ -0,0 +1,236 @@
+// Package rir reads the address blocks from RIR statistics exchange files,
+// like delegated-ripencc-extended-latest, to be merged with mergeips.Merge
+package rir
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/Djarvur/go-mergeips/internal/int128"
+	"github.com/Djarvur/go-mergeips/internal/masks"
+	"github.com/Djarvur/go-mergeips/iprange"
+)
+
+// Errors
+var (
+	ErrInvalidRecord = errors.New("invalid record")
+	ErrOutOfRange    = errors.New("block exceeds the address family")
+)
+
+// Address types
+const (
+	TypeIPv4 = "ipv4"
+	TypeIPv6 = "ipv6"
+)
+
+// Record is the address block record of the file:
+// registry|cc|type|start|value|date|status[|opaque-id[|extensions...]]
+// Value is the number of addresses for IPv4 and the prefix length for IPv6.
+type Record struct {
+	Line     int
+	Registry string
+	Country  string
+	Type     string
+	Start    net.IP
+	Value    string
+	Date     string
+	Status   string
+	OpaqueID string
+	// First and Last are the block boundaries
+	First net.IP
+	Last  net.IP
+}
+
+// Filter selects the records, empty list matches any value
+// Values are compared case-insensitively
+type Filter struct {
+	Registries []string
+	Countries  []string
+	Statuses   []string
+}
+
+// Reader reads the address block records, skipping the header, summary, comment and ASN lines
+// and the records not matching the filter.
+// Compatible with mergeips.Scanner, Text returns the block as begin-end range
+type Reader struct {
+	s      *bufio.Scanner
+	filter Filter
+	line   int
+	record Record
+	err    error
+}
+
+// NewReader returns the Reader for the file provided
+func NewReader(r io.Reader, filter Filter) *Reader {
+	return &Reader{s: bufio.NewScanner(r), filter: filter}
+}
+
+// Read returns the blocks of all the records matching the filter as subnets, ready for mergeips.Merge
+func Read(r io.Reader, filter Filter) (res []*net.IPNet, err error) {
+	reader := NewReader(r, filter)
+
+	for reader.Scan() {
+		record := reader.Record()
+
+		nets, err := iprange.Merge(record.First, record.Last) // nolint: govet
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, nets...)
+	}
+
+	if err = reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Scan advances the Reader to the next record matching the filter
+func (r *Reader) Scan() bool {
+	for r.err == nil && r.s.Scan() {
+		r.line++
+
+		fields := strings.Split(strings.TrimSpace(r.s.Text()), "|")
+		if !isRecord(fields) {
+			continue
+		}
+
+		record, err := parseRecord(fields)
+		if err != nil {
+			r.err = fmt.Errorf("line %d: %w", r.line, err)
+			return false
+		}
+
+		if r.filter.match(record) {
+			record.Line = r.line
+			r.record = record
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Record returns the current record
+func (r *Reader) Record() Record {
+	return r.record
+}
+
+// Text returns the current record block as begin-end range
+func (r *Reader) Text() string {
+	return r.record.First.String() + "-" + r.record.Last.String()
+}
+
+// Err returns the first error happened
+func (r *Reader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return r.s.Err()
+}
+
+// isRecord tells the address block record from the header, summary, comment and ASN lines
+func isRecord(fields []string) bool {
+	switch {
+	case len(fields) < 7 || strings.HasPrefix(fields[0], "#"):
+		return false
+	case fields[1] == "*" || fields[5] == "summary":
+		return false
+	}
+
+	return fields[2] == TypeIPv4 || fields[2] == TypeIPv6
+}
+
+func parseRecord(fields []string) (Record, error) {
+	record := Record{
+		Registry: fields[0],
+		Country:  fields[1],
+		Type:     fields[2],
+		Value:    fields[4],
+		Date:     fields[5],
+		Status:   fields[6],
+	}
+
+	if len(fields) > 7 {
+		record.OpaqueID = fields[7]
+	}
+
+	record.Start = net.ParseIP(fields[3])
+	if record.Start == nil {
+		return record, fmt.Errorf("start %q: %w", fields[3], ErrInvalidRecord)
+	}
+
+	var (
+		start = int128.Uint128FromIP(record.Start)
+		last  int128.Uint128
+		bits  = 128
+	)
+
+	if record.Type == TypeIPv4 {
+		if record.Start = record.Start.To4(); record.Start == nil {
+			return record, fmt.Errorf("start %q: %w", fields[3], ErrInvalidRecord)
+		}
+
+		count, err := strconv.ParseUint(record.Value, 10, 64)
+		if err != nil || count == 0 {
+			return record, fmt.Errorf("count %q: %w", record.Value, ErrInvalidRecord)
+		}
+
+		bits = 32
+		start = int128.Uint128FromIP(record.Start)
+
+		var overflow bool
+		if last, overflow = start.Add(int128.Uint128FromUint64s(0, count-1)); overflow ||
+			last.Cmp(int128.Uint128FromUint64s(0, math.MaxUint32)) > 0 {
+			return record, fmt.Errorf("%s+%d: %w", record.Start, count, ErrOutOfRange)
+		}
+	} else {
+		if !strings.Contains(fields[3], ":") {
+			return record, fmt.Errorf("start %q: %w", fields[3], ErrInvalidRecord)
+		}
+
+		ones, err := strconv.Atoi(record.Value)
+		if err != nil || ones < 0 || ones > 128 {
+			return record, fmt.Errorf("prefix length %q: %w", record.Value, ErrInvalidRecord)
+		}
+
+		mask := masks.Get(ones, 128).Mask
+		start = start.And(mask)
+		last = start.RangeEnd(mask)
+	}
+
+	record.First = start.IP(bits)
+	record.Last = last.IP(bits)
+
+	return record, nil
+}
+
+func (f Filter) match(record Record) bool {
+	return matchAny(f.Registries, record.Registry) &&
+		matchAny(f.Countries, record.Country) &&
+		matchAny(f.Statuses, record.Status)
+}
+
+func matchAny(list []string, value string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, s := range list {
+		if strings.EqualFold(s, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package rir_test
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/Djarvur/go-mergeips/rir"
+)
+
+const testFile = `2|ripencc|1700000000|5|19830705|20231114|+0100
+# comment
+ripencc|*|ipv4|*|3|summary
+ripencc|*|ipv6|*|2|summary
+ripencc|*|asn|*|1|summary
+ripencc|FR|asn|1234|1|19930901|allocated|abc
+ripencc|FR|ipv4|2.0.0.0|1048576|20100712|allocated|abc
+ripencc|FR|ipv4|5.10.0.0|768|20120101|assigned|def
+ripencc|DE|ipv4|5.1.0.0|256|20120101|allocated|ghi
+ripencc|FR|ipv6|2001:660::|32|19990727|allocated|abc
+ripencc||ipv4|192.0.2.0|300|20200101|available
+`
+
+type testReadRow struct {
+	input    string
+	filter   rir.Filter
+	expected []string
+	err      error
+}
+
+var testReadData = []testReadRow{
+	{
+		input:    testFile,
+		filter:   rir.Filter{Countries: []string{"fr"}},
+		expected: []string{"2.0.0.0/12", "5.10.0.0/23", "5.10.2.0/24", "2001:660::/32"},
+	},
+	{
+		input:    testFile,
+		filter:   rir.Filter{Countries: []string{"FR", "DE"}, Statuses: []string{"allocated"}},
+		expected: []string{"2.0.0.0/12", "5.1.0.0/24", "2001:660::/32"},
+	},
+	{
+		input:    testFile,
+		filter:   rir.Filter{Statuses: []string{"available"}},
+		expected: []string{"192.0.2.0/24", "192.0.3.0/27", "192.0.3.32/29", "192.0.3.40/30"},
+	},
+	{
+		input:    testFile,
+		filter:   rir.Filter{Registries: []string{"arin"}},
+		expected: nil,
+	},
+	{
+		input:    "apnic|JP|ipv6|2001:200::/35|32|19990813|allocated\n",
+		expected: nil,
+		err:      rir.ErrInvalidRecord,
+	},
+	{
+		input:    "apnic|JP|ipv4|1.0.0.0|many|19990813|allocated\n",
+		expected: nil,
+		err:      rir.ErrInvalidRecord,
+	},
+	{
+		input:    "apnic|JP|ipv6|2001:200::|129|19990813|allocated\n",
+		expected: nil,
+		err:      rir.ErrInvalidRecord,
+	},
+	{
+		input:    "iana|ZZ|ipv4|255.255.255.0|257|19990813|reserved\n",
+		expected: nil,
+		err:      rir.ErrOutOfRange,
+	},
+	{
+		input:    "iana|ZZ|ipv4|0.0.0.0|4294967296|19990813|reserved\n",
+		expected: []string{"0.0.0.0/0"},
+	},
+}
+
+func TestRead(t *testing.T) {
+	for i, row := range testReadData {
+		nets, err := rir.Read(strings.NewReader(row.input), row.filter)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%d: got error %v, expected %v", i, err, row.err)
+		}
+
+		if diff := deep.Equal(netStrings(nets), row.expected); diff != nil {
+			t.Errorf("%d: %v", i, diff)
+		}
+	}
+}
+
+func TestReaderScan(t *testing.T) {
+	r := rir.NewReader(strings.NewReader(testFile), rir.Filter{Countries: []string{"FR"}, Statuses: []string{"assigned"}})
+
+	nets, err := mergeips.Scan(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := deep.Equal(netStrings(mergeips.Merge(nets)), []string{"5.10.0.0/23", "5.10.2.0/24"}); diff != nil {
+		t.Error(diff)
+	}
+
+	record := r.Record()
+	if record.Line != 8 || record.OpaqueID != "def" || record.Date != "20120101" {
+		t.Errorf("unexpected record %+v", record)
+	}
+}
+
+func netStrings(nets []*net.IPNet) []string {
+	if len(nets) == 0 {
+		return nil
+	}
+
+	res := make([]string, 0, len(nets))
+
+	for _, n := range nets {
+		res = append(res, n.String())
+	}
+
+	return res
+}
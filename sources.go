@@ -0,0 +1,149 @@
+package mergeips
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/Djarvur/go-mergeips/internal/subnet"
+)
+
+// Source identifies the input entry: Label is a caller-supplied one, like file name,
+// Line is 1-based line number, if any, Input is the text the entry was parsed from
+type Source struct {
+	Label string
+	Line  int
+	Input string
+}
+
+// String returns the source as label:line, parts missing are omitted
+func (s Source) String() string {
+	switch {
+	case s.Line == 0:
+		return s.Label
+	case s.Label == "":
+		return strconv.Itoa(s.Line)
+	}
+
+	return s.Label + ":" + strconv.Itoa(s.Line)
+}
+
+// Entry is a subnet with the input entry it was parsed from
+type Entry struct {
+	Net    *net.IPNet
+	Source Source
+}
+
+// Sourced is a merged subnet with all the input entries contributed to it,
+// either merged into it or removed as included
+type Sourced struct {
+	Net     *net.IPNet
+	Sources []Source
+}
+
+// ScanSources is used to parse source to the list of Entry
+// See Parser.ScanSources
+func ScanSources(s Scanner, label string) ([]Entry, error) {
+	return Parser{}.ScanSources(s, label)
+}
+
+// ScanSources is used to parse source to the list of Entry
+// Every entry is tagged with the label provided and the line number
+func (p Parser) ScanSources(s Scanner, label string) (res []Entry, err error) {
+	for line := 1; s.Scan(); line++ {
+		entries, err := p.ParseSource(Source{Label: label, Line: line, Input: s.Text()}) // nolint: govet
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, entries...)
+	}
+
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ParseSource parses source Input the same way Parse does
+// and tags every subnet with the source
+func (p Parser) ParseSource(src Source) ([]Entry, error) {
+	nets, err := p.Parse(src.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Entry, 0, len(nets))
+
+	for _, n := range nets {
+		res = append(res, Entry{Net: n, Source: src})
+	}
+
+	return res, nil
+}
+
+// MergeWithSources merges the list of Entry to the smallest possible set, the same way Merge does,
+// and returns every subnet merged with the sources it is made of, in the input order
+func MergeWithSources(entries []Entry) []Sourced {
+	merged := subnet.MergeSourced(sourcedEntries(entries))
+	res := make([]Sourced, 0, len(merged))
+
+	for _, s := range merged {
+		res = append(res, Sourced{Net: s.IPNet(), Sources: entrySources(entries, s.Sources)})
+	}
+
+	return res
+}
+
+// sourcedEntries converts entries to subnet.Sourced referring the entries by index
+func sourcedEntries(entries []Entry) []subnet.Sourced {
+	res := make([]subnet.Sourced, 0, len(entries))
+
+	for i, e := range entries {
+		res = append(res, subnet.Sourced{Subnet: subnet.FromIPNet(e.Net), Sources: []int{i}})
+	}
+
+	return res
+}
+
+// entrySources returns the sources of the entries referred, every source once
+func entrySources(entries []Entry, idx []int) []Source {
+	var (
+		res  = make([]Source, 0, len(idx))
+		seen = make(map[Source]bool, len(idx))
+	)
+
+	for _, i := range idx {
+		if src := entries[i].Source; !seen[src] {
+			seen[src] = true
+			res = append(res, src)
+		}
+	}
+
+	return res
+}
+
+// dedupKept is subnet.DedupSortedSourced returning the entries kept with their own sources only,
+// without the sources of the entries removed
+func dedupKept(sorted []subnet.Sourced, dropped func(kept, removed subnet.Sourced)) []subnet.Sourced {
+	own := make(map[subnet.Subnet][]int)
+
+	deduped := subnet.DedupSortedSourced(
+		sorted,
+		func(kept, removed subnet.Sourced) {
+			own[kept.Subnet] = kept.Sources
+
+			if dropped != nil {
+				dropped(kept, removed)
+			}
+		},
+	)
+
+	for i := range deduped {
+		if sources, ok := own[deduped[i].Subnet]; ok {
+			deduped[i].Sources = sources
+		}
+	}
+
+	return deduped
+}
@@ -0,0 +1,59 @@
+package mergeips_test
+
+import (
+	"net"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+func TestMergeWithSources(t *testing.T) {
+	a, err := mergeips.ScanSources(&stringSliceScanner{data: []string{"203.0.113.0/25", "198.51.100.7"}, next: -1}, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := mergeips.ScanSources(&stringSliceScanner{data: []string{"203.0.113.128-203.0.113.255", "203.0.113.9"}, next: -1}, "b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	extra, err := mergeips.Parser{}.ParseSource(mergeips.Source{Label: "api", Input: "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := mergeips.MergeWithSources(append(append(a, b...), extra...))
+	expected := []mergeips.Sourced{
+		{
+			Net:     parseCIDR("198.51.100.7/32"),
+			Sources: []mergeips.Source{{Label: "a.txt", Line: 2, Input: "198.51.100.7"}},
+		},
+		{
+			Net: parseCIDR("203.0.113.0/24"),
+			Sources: []mergeips.Source{
+				{Label: "a.txt", Line: 1, Input: "203.0.113.0/25"},
+				{Label: "b.txt", Line: 1, Input: "203.0.113.128-203.0.113.255"},
+				{Label: "b.txt", Line: 2, Input: "203.0.113.9"},
+			},
+		},
+		{
+			Net:     parseCIDR("2001:db8::/32"),
+			Sources: []mergeips.Source{{Label: "api", Input: "2001:db8::/32"}},
+		},
+	}
+
+	if diff := deep.Equal(merged, expected); diff != nil {
+		t.Errorf("got %v, expected %v: %v", merged, expected, diff)
+	}
+
+	plain := make([]*net.IPNet, 0, len(merged))
+	for _, s := range merged {
+		plain = append(plain, s.Net)
+	}
+
+	if diff := deep.Equal(plain, mergeips.Merge([]*net.IPNet{a[0].Net, a[1].Net, b[0].Net, b[1].Net, extra[0].Net})); diff != nil {
+		t.Errorf("got %v, expected the same as Merge: %v", plain, diff)
+	}
+}
@@ -0,0 +1,127 @@
+package mergeips
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/Djarvur/go-mergeips/internal/parseerr"
+	"github.com/Djarvur/go-mergeips/ipnet"
+)
+
+// ZonedNet is the subnet with IPv6 zone identifier, like fe80::/64%eth0, Zone is empty for no zone
+type ZonedNet struct {
+	Net  *net.IPNet
+	Zone string
+}
+
+// String returns the subnet in CIDR notation with the zone appended, if any
+func (z ZonedNet) String() string {
+	if z.Zone == "" {
+		return ipnet.String(z.Net)
+	}
+
+	return ipnet.String(z.Net) + "%" + z.Zone
+}
+
+// ScanZoned is used to parse source to the list of ZonedNet
+// See Parser.ScanZoned
+func ScanZoned(s Scanner) ([]ZonedNet, error) {
+	return Parser{}.ScanZoned(s)
+}
+
+// ScanZoned is used to parse source to the list of ZonedNet, see ParseZoned
+func (p Parser) ScanZoned(s Scanner) (res []ZonedNet, err error) {
+	for s.Scan() {
+		nets, err := p.ParseZoned(s.Text()) // nolint: govet
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, nets...)
+	}
+
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ParseZoned parses a string the same way Parse does, accepting IPv6 zone identifier at the end,
+// like fe80::1%eth0 or fe80::/64%eth0, the zone applies to all the subnets parsed.
+// The zone is to be of letters, digits, '_' and '.' only, so '-', '+' and ':' after it are never taken for its part.
+func (p Parser) ParseZoned(s string) ([]ZonedNet, error) {
+	input, zone := s, ""
+
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		input, zone = s[:i], s[i+1:]
+
+		if !isZone(zone) {
+			return nil, &ParseError{Input: s, Offset: i + 1, Reason: ReasonZone, Family: parseerr.FamilyOf(input)}
+		}
+	}
+
+	nets, err := p.ParseContext(context.Background(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]ZonedNet, 0, len(nets))
+
+	for _, n := range nets {
+		if zone != "" && len(n.IP) == net.IPv4len {
+			return nil, &ParseError{Input: s, Offset: len(input) + 1, Reason: ReasonZone, Family: FamilyIPv4, Err: ErrZonedIPv4}
+		}
+
+		res = append(res, ZonedNet{Net: n, Zone: zone})
+	}
+
+	return res, nil
+}
+
+// MergeZoned merges every zone subnets the same way Merge does, never merging the subnets of different zones.
+// The subnets with no zone go first, then the zones in lexical order.
+func MergeZoned(nets []ZonedNet) []ZonedNet {
+	var (
+		byZone = make(map[string][]*net.IPNet)
+		zones  []string
+	)
+
+	for _, n := range nets {
+		if _, ok := byZone[n.Zone]; !ok {
+			zones = append(zones, n.Zone)
+		}
+
+		byZone[n.Zone] = append(byZone[n.Zone], n.Net)
+	}
+
+	sort.Strings(zones)
+
+	res := make([]ZonedNet, 0, len(nets))
+
+	for _, zone := range zones {
+		for _, n := range Merge(byZone[zone]) {
+			res = append(res, ZonedNet{Net: n, Zone: zone})
+		}
+	}
+
+	return res
+}
+
+// isZone returns true for the zone identifier of letters, digits, '_' and '.' only, like eth0 or 12,
+// so the range end or the count after the zone, like fe80::1%eth0-fe80::5, is never taken for its part
+func isZone(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			return false
+		}
+	}
+
+	return true
+}
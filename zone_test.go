@@ -0,0 +1,104 @@
+package mergeips_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Djarvur/go-mergeips"
+	"github.com/go-test/deep"
+)
+
+type testParseZonedRow struct {
+	in       string
+	expected []string
+	reason   mergeips.ParseErrorReason
+	offset   int
+	err      error
+}
+
+var testParseZonedData = []testParseZonedRow{
+	{in: "fe80::1%eth0", expected: []string{"fe80::1/128%eth0"}},
+	{in: "fe80::/64%eth0", expected: []string{"fe80::/64%eth0"}},
+	{in: "fe80::1-fe80::2%br_lan", expected: []string{"fe80::1/128%br_lan", "fe80::2/128%br_lan"}},
+	{in: "fe80::1%12", expected: []string{"fe80::1/128%12"}},
+	{in: "fe80::1", expected: []string{"fe80::1/128"}},
+	{in: "10.0.0.0/8", expected: []string{"10.0.0.0/8"}},
+	{in: "fe80::1%", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "fe80::1%eth0%eth1", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "fe80::1%eth0/64", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "fe80::1%eth0-fe80::5", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "fe80::1%eth0+4", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "fe80::1%eth0:1", reason: mergeips.ReasonZone, offset: 8, err: mergeips.ErrInputInvalid},
+	{in: "10.0.0.1%eth0", reason: mergeips.ReasonZone, offset: 9, err: mergeips.ErrZonedIPv4},
+	{in: "fe80::g%eth0", reason: mergeips.ReasonBadAddress, offset: 6, err: mergeips.ErrInputInvalid},
+}
+
+func TestParseZoned(t *testing.T) {
+	for _, row := range testParseZonedData {
+		nets, err := mergeips.Parser{}.ParseZoned(row.in)
+		if !errors.Is(err, row.err) {
+			t.Errorf("%s: got error %v, expected %v", row.in, err, row.err)
+		}
+
+		var perr *mergeips.ParseError
+		if err != nil && (!errors.As(err, &perr) || perr.Reason != row.reason || perr.Offset != row.offset) {
+			t.Errorf("%s: got error %v, expected %v at %d", row.in, err, row.reason, row.offset)
+		}
+
+		var got []string
+
+		for _, n := range nets {
+			got = append(got, n.String())
+		}
+
+		if diff := deep.Equal(got, row.expected); diff != nil {
+			t.Errorf("%s: %v", row.in, diff)
+		}
+	}
+}
+
+func TestParseZone(t *testing.T) {
+	for in, family := range map[string]mergeips.Family{
+		"fe80::1%eth0":  mergeips.FamilyIPv6,
+		"10.0.0.1%eth0": mergeips.FamilyIPv4,
+	} {
+		_, err := mergeips.Parse(in, false)
+
+		var perr *mergeips.ParseError
+		if !errors.Is(err, mergeips.ErrZoned) || !errors.As(err, &perr) || perr.Reason != mergeips.ReasonZone ||
+			perr.Offset != strings.IndexByte(in, '%') || perr.Family != family {
+			t.Errorf("%s: got error %v, expected %v", in, err, mergeips.ErrZoned)
+		}
+	}
+}
+
+func TestMergeZoned(t *testing.T) {
+	nets, err := mergeips.ScanZoned(&stringSliceScanner{
+		data: []string{
+			"fe80::/65%eth1",
+			"fe80::/65%eth0",
+			"fe80::8000:0:0:0/65%eth0",
+			"fe80::8000:0:0:0/65",
+			"fe80::/65",
+			"fe80::1%eth1",
+			"10.0.0.0/8",
+		},
+		next: -1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+
+	for _, n := range mergeips.MergeZoned(nets) {
+		got = append(got, n.String())
+	}
+
+	expected := []string{"10.0.0.0/8", "fe80::/64", "fe80::/64%eth0", "fe80::/65%eth1"}
+
+	if diff := deep.Equal(got, expected); diff != nil {
+		t.Error(diff)
+	}
+}